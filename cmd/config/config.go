@@ -1,14 +1,19 @@
 package config
 
-import "github.com/ilyakaznacheev/cleanenv"
+import (
+	"time"
+
+	"github.com/ilyakaznacheev/cleanenv"
+)
 
 type Parser struct {
 
-	// TimeFormat is a format for time.Time.Format() function
+	// TimeFormat is a format of the input time for time.Parse() function
 	// See https://golang.org/pkg/time/#Time.Format
 	//
-	// Example: "15:04" for taking only hours and minutes
-	TimeFormat string `env:"TIME_FORMAT" env-default:"15:04"`
+	// Example: "15:04" for taking only hours and minutes, "15:04:05" for seconds,
+	// TIME_FORMAT is used, if INPUT_TIME_FORMAT isn't set
+	TimeFormat string `env:"INPUT_TIME_FORMAT,TIME_FORMAT" env-default:"15:04"`
 
 	// TimeSeparator is a separator between start and end time
 	//
@@ -27,15 +32,65 @@ type Parser struct {
 
 	// EventsChanSize is a size of events channel
 	EventsChanSize int `env:"EVENTS_CHAN_SIZE" env-default:"10"`
+
+	// LenientOrdering allows events to come slightly out of chronological order.
+	//
+	// When disabled, an event that happens before the previous one is a format error.
+	// When enabled, events are sorted inside a window of ReorderWindowSize events,
+	// only an event that is late more than the window is a format error.
+	LenientOrdering bool `env:"LENIENT_ORDERING" env-default:"false"`
+
+	// ReorderWindowSize is a count of events kept for sorting in lenient ordering mode
+	ReorderWindowSize int `env:"REORDER_WINDOW_SIZE" env-default:"10"`
 }
 
 type Processor struct {
 
-	// TimeFormat is a format for time.Time.Format() function
+	// TimeFormat is a format of the output time for time.Time.Format() function
 	// See https://golang.org/pkg/time/#Time.Format
 	//
-	// Example: "15:04" for taking only hours and minutes
-	TimeFormat string `env:"TIME_FORMAT" env-default:"15:04"`
+	// Example: "15:04" for taking only hours and minutes, usage time is printed with seconds,
+	// if the format has them, TIME_FORMAT is used, if OUTPUT_TIME_FORMAT isn't set
+	TimeFormat string `env:"OUTPUT_TIME_FORMAT,TIME_FORMAT" env-default:"15:04"`
+
+	// OutputFormat is a format of the processing results
+	//
+	// One of: "text" for the lines described in the task, "json" for a single JSON document
+	OutputFormat string `env:"OUTPUT_FORMAT" env-default:"text"`
+
+	// MaxQueueLength is a count of clients, which can wait for a table,
+	// the next waiting client leaves the club
+	//
+	// 0 means, that the queue length may not exceed the tables count
+	MaxQueueLength int `env:"MAX_QUEUE_LENGTH" env-default:"0"`
+
+	// QueuePolicy is an order, in which waiting clients take free tables
+	//
+	// One of: "fifo" for the arrival order, "priority" for the club members before other clients
+	QueuePolicy string `env:"QUEUE_POLICY" env-default:"fifo"`
+
+	// ReservationGrace is a time after the start of the reservation,
+	// when the reserved table is released, if the client hasn't sat down
+	ReservationGrace time.Duration `env:"RESERVATION_GRACE" env-default:"15m"`
+}
+
+type Billing struct {
+
+	// Policy is a way of rounding the table usage time before charging
+	//
+	// One of: "hourly", "per-minute", "blocks"
+	Policy string `env:"BILLING_POLICY" env-default:"hourly"`
+
+	// BlockSize is a size of the charged time block for the "blocks" policy
+	//
+	// Example: "15m" for charging every started quarter of an hour
+	BlockSize time.Duration `env:"BILLING_BLOCK_SIZE" env-default:"15m"`
+
+	// GracePeriod is a time at the beginning of the session, which is free
+	GracePeriod time.Duration `env:"BILLING_GRACE_PERIOD" env-default:"0s"`
+
+	// MinCharge is a minimal amount a client pays for the session, 0 for none
+	MinCharge int `env:"BILLING_MIN_CHARGE" env-default:"0"`
 }
 
 func NewParserConfig() (*Parser, error) {
@@ -55,3 +110,12 @@ func NewProcessorConfig() (*Processor, error) {
 
 	return &cfg, nil
 }
+
+func NewBillingConfig() (*Billing, error) {
+	var cfg Billing
+	if err := cleanenv.ReadEnv(&cfg); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
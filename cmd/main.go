@@ -1,129 +1,273 @@
 package main
 
 import (
-	"bufio"
-	"bytes"
 	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 	"yadro-intern/cmd/config"
+	"yadro-intern/internal/apierror"
+	"yadro-intern/internal/billing"
 	"yadro-intern/internal/model"
-	"yadro-intern/internal/parser"
+	"yadro-intern/internal/output"
 	"yadro-intern/internal/processor"
-	"yadro-intern/internal/storage"
 )
 
-func parseArgs() (string, error) {
-	if len(os.Args) < 2 {
-		return "", fmt.Errorf("usage: ./yadro-intern <filename>")
+// exit codes are shared by all commands
+const (
+	exitOK = 0
+
+	// exitInvalidInput means, that the input file has format errors.
+	exitInvalidInput = 1
+
+	// exitUsage means, that the command line arguments are wrong.
+	exitUsage = 2
+
+	// exitFailure means, that the command failed because of configuration or I/O.
+	exitFailure = 3
+)
+
+const usage = `usage: ./yadro-intern <command> [flags] [args]
+
+commands:
+  run       process events of the file and print the results (default)
+  validate  report all format errors of the file
+  report    print only the revenue of the tables
+  state     print the state of the club at the moment
+  simulate  generate a random input file
+  serve     start the live session with the HTTP API for the events
+
+./yadro-intern <filename> is the same as ./yadro-intern run <filename>
+the filename "-" means stdin
+run "./yadro-intern <command> --help" for the command flags
+`
+
+// command is a subcommand of the CLI, run returns the exit code.
+type command struct {
+	name        string
+	usage       string
+	description string
+	run         func(c *cli, cmd *command, args []string) int
+}
+
+var commands = []*command{runCommand, validateCommand, reportCommand, stateCommand, simulateCommand, serveCommand}
+
+// cli keeps the streams of the program, so commands can be tested.
+//
+// Results are written to stdout, errors and usage are written to stderr.
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func (c *cli) run(args []string) int {
+	if len(args) == 0 {
+		c.printf("%s", usage)
+		return exitUsage
 	}
 
-	return os.Args[1], nil
+	switch args[0] {
+	case "-h", "-help", "--help", "help":
+		_, _ = fmt.Fprint(c.stdout, usage)
+		return exitOK
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(c, cmd, args[1:])
+		}
+	}
+
+	// backward compatible way of calling the program with the file only
+	if args[0] == "-" || len(args[0]) > 0 && args[0][0] != '-' {
+		return runCommand.run(c, runCommand, args)
+	}
+
+	c.printf("unknown command: %s\n%s", args[0], usage)
+	return exitUsage
 }
 
-func openFile(filename string) (*os.File, error) {
-	f, err := os.Open(filepath.Clean(filename))
-	switch {
-	case err == nil:
-	case errors.Is(err, os.ErrNotExist):
-		return nil, fmt.Errorf("file does not exist: %s", filename)
-	case errors.Is(err, os.ErrPermission):
-		return nil, fmt.Errorf("not enough permissions to open file: %s", filename)
-	default:
-		return nil, fmt.Errorf("could not open file: %s", err)
+// parseFlags parses the command flags and checks the count of positional arguments.
+//
+// ok is false, when the command should exit with the returned code,
+// for example, after printing the help.
+func (c *cli) parseFlags(cmd *command, fs *flag.FlagSet, args []string, posArgs int) (code int, ok bool) {
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(fs.Output(), "usage: ./yadro-intern %s\n\n%s\n", cmd.usage, cmd.description)
+		fs.PrintDefaults()
 	}
 
-	return f, nil
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK, false
+		}
+
+		return exitUsage, false
+	}
+
+	if fs.NArg() != posArgs {
+		fs.Usage()
+		return exitUsage, false
+	}
+
+	return exitOK, true
 }
 
-func main() {
-	log.SetFlags(0)
+func (c *cli) printf(format string, args ...any) {
+	_, _ = fmt.Fprintf(c.stderr, format, args...)
+}
+
+// fail prints the error and returns the exit code matching it.
+func (c *cli) fail(err error) int {
+	c.printf("%s\n", err)
+
+	var (
+		parseErr      *apierror.ParseError
+		validationErr *apierror.ValidationError
+	)
+
+	if errors.As(err, &parseErr) || errors.As(err, &validationErr) {
+		return exitInvalidInput
+	}
+
+	return exitFailure
+}
 
+type appConfig struct {
+	parser        *config.Parser
+	processor     *config.Processor
+	billingPolicy billing.Policy
+}
+
+func loadConfig() (*appConfig, error) {
 	parserConfig, err := config.NewParserConfig()
 	if err != nil {
-		log.Println("checkout configuration:", err)
-		return
+		return nil, fmt.Errorf("checkout configuration: %w", err)
 	}
 
 	processorConfig, err := config.NewProcessorConfig()
 	if err != nil {
-		log.Println("checkout configuration:", err)
-		return
+		return nil, fmt.Errorf("checkout configuration: %w", err)
+	}
+
+	if err = validateTimeFormat(parserConfig.TimeFormat, parserConfig.EventInfoSeparator, parserConfig.TimeSeparator); err != nil {
+		return nil, fmt.Errorf("checkout configuration: input time format: %w", err)
+	}
+
+	if err = validateTimeFormat(processorConfig.TimeFormat); err != nil {
+		return nil, fmt.Errorf("checkout configuration: output time format: %w", err)
+	}
+
+	if !output.IsKnownFormat(processorConfig.OutputFormat) {
+		return nil, fmt.Errorf("checkout configuration: unknown output format: %s", processorConfig.OutputFormat)
+	}
+
+	if !processor.IsKnownQueuePolicy(processorConfig.QueuePolicy) {
+		return nil, fmt.Errorf("checkout configuration: unknown queue policy: %s", processorConfig.QueuePolicy)
 	}
 
-	filename, err := parseArgs()
+	if processorConfig.MaxQueueLength < 0 {
+		return nil, fmt.Errorf("checkout configuration: max queue length must not be negative: %d", processorConfig.MaxQueueLength)
+	}
+
+	billingConfig, err := config.NewBillingConfig()
 	if err != nil {
-		log.Println(err)
-		return
+		return nil, fmt.Errorf("checkout configuration: %w", err)
 	}
 
-	f, err := openFile(filename)
+	billingPolicy, err := billing.NewPolicy(billingConfig)
 	if err != nil {
-		log.Println(err)
-		return
+		return nil, fmt.Errorf("checkout configuration: %w", err)
 	}
 
-	defer func() {
-		if err = f.Close(); err != nil {
-			log.Println("failed to close file:", err)
-		}
-	}()
-
-	// reading core file data in synchronous way
-	// because all others operations depend on it
-	//
-	// can use fan-out pattern here, but still fine in sync way
-	fp := parser.NewFileParser(bufio.NewScanner(f), parserConfig)
-	coreData, err := fp.ReadCoreData()
+	return &appConfig{
+		parser:        parserConfig,
+		processor:     processorConfig,
+		billingPolicy: billingPolicy,
+	}, nil
+}
+
+// validateTimeFormat checks, that the time format keeps the clock time:
+// the formatted time is parsed back with the same hours, minutes and seconds.
+//
+// The formatted time must not contain the separators, otherwise the input lines are split by it.
+func validateTimeFormat(timeFormat string, separators ...string) error {
+	clock := time.Date(0, 1, 1, 13, 47, 59, 0, time.UTC)
+	formatted := clock.Format(timeFormat)
+
+	parsed, err := time.Parse(timeFormat, formatted)
 	if err != nil {
-		log.Println(err)
-		return
-	}
-
-	// reading events are done in a separate goroutine
-	// made for making performance better?
-	// (probably not, in case of printing errors first)
-	eventsChan := fp.ReadEvents(coreData.TablesCount)
-
-	// in case of parallel processing:
-	//	by the task, we should print error, if it occurs
-	//  without any additional information, for that purpose
-	//  we can use buffer to store all successfully parsed events here
-	var temporaryBuffer = bytes.NewBuffer(nil)
-
-	p := processor.NewEventProcessor(
-		temporaryBuffer,
-		processorConfig,
-		coreData,
-		storage.NewInMemoryStorage[int, *model.IncomingEvent](),
-		storage.NewInMemoryStorage[int, *model.RevenueStats](),
-		storage.NewInMemoryStorage[string, int](),
-		storage.NewInMemoryQueue[model.ClientData](nil),
-	)
+		return fmt.Errorf("%q can't be parsed back: %s", timeFormat, err)
+	}
+
+	if parsed.Hour() != clock.Hour() || parsed.Minute() != clock.Minute() {
+		return fmt.Errorf("%q doesn't keep hours and minutes", timeFormat)
+	}
 
-	done := make(chan error)
-	defer close(done)
+	if model.HasSeconds(timeFormat) && parsed.Second() != clock.Second() {
+		return fmt.Errorf("%q doesn't keep seconds", timeFormat)
+	}
 
-	go func() {
-		e := p.ProcessEvents(eventsChan)
-		if e != nil {
-			done <- e
-		} else {
-			p.ShowRevenue()
-			done <- nil
+	for _, separator := range separators {
+		if separator != "" && strings.Contains(formatted, separator) {
+			return fmt.Errorf("%q contains the separator %q", timeFormat, separator)
 		}
-	}()
+	}
 
-	if err = <-done; err != nil {
-		log.Println(err)
-		return
+	return nil
+}
+
+func openFile(filename string) (*os.File, error) {
+	f, err := os.Open(filepath.Clean(filename))
+	switch {
+	case err == nil:
+	case errors.Is(err, os.ErrNotExist):
+		return nil, fmt.Errorf("file does not exist: %s", filename)
+	case errors.Is(err, os.ErrPermission):
+		return nil, fmt.Errorf("not enough permissions to open file: %s", filename)
+	default:
+		return nil, fmt.Errorf("could not open file: %s", err)
+	}
+
+	return f, nil
+}
+
+// openInput opens the input file, "-" means stdin.
+func (c *cli) openInput(filename string) (io.ReadCloser, error) {
+	if filename == "-" {
+		return io.NopCloser(c.stdin), nil
+	}
+
+	return openFile(filename)
+}
+
+// createFile creates the file for the command results, "-" means stdout.
+func (c *cli) createFile(filename string) (io.WriteCloser, error) {
+	if filename == "-" {
+		return nopWriteCloser{c.stdout}, nil
 	}
 
-	// no errors mean that all events are successfully processed,
-	// so we can print all successfully parsed events
-	for scanner := bufio.NewScanner(temporaryBuffer); scanner.Scan(); {
-		log.Println(scanner.Text())
+	f, err := os.Create(filepath.Clean(filename))
+	if err != nil {
+		return nil, fmt.Errorf("could not create file: %s", err)
 	}
+
+	return f, nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+func main() {
+	c := &cli{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	os.Exit(c.run(os.Args[1:]))
 }
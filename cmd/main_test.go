@@ -1,46 +1,780 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+	"yadro-intern/internal/model"
+	"yadro-intern/internal/storage"
 )
 
-func TestParseArgs(t *testing.T) {
+const exampleInput = "../build/input.txt"
+
+const exampleOutput = `09:00
+08:48 1 client1
+08:48 13 NotOpenYet
+09:41 1 client1
+09:48 1 client2
+09:52 3 client1
+09:52 13 ICanWaitNoLonger!
+09:54 2 client1 1
+10:25 2 client2 2
+10:58 1 client3
+10:59 2 client3 3
+11:30 1 client4
+11:35 2 client4 2
+11:35 13 PlaceIsBusy
+11:45 3 client4
+12:33 4 client1
+12:33 12 client4 1
+12:43 4 client2
+15:52 4 client4
+19:00 11 client3
+19:00
+1 70 05:58
+2 30 02:18
+3 90 08:01
+`
+
+func runCLI(args ...string) (code int, stdout, stderr string) {
+	return runCLIWithInput("", args...)
+}
+
+func runCLIWithInput(stdin string, args ...string) (code int, stdout, stderr string) {
+	var out, errOut bytes.Buffer
+	c := &cli{stdin: strings.NewReader(stdin), stdout: &out, stderr: &errOut}
+	code = c.run(args)
+	return code, out.String(), errOut.String()
+}
+
+func writeTempFile(t *testing.T, content string) string {
+	t.Helper()
+
+	filename := filepath.Join(t.TempDir(), "input.txt")
+	if err := os.WriteFile(filename, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write input: %s", err)
+	}
+
+	return filename
+}
+
+func TestCLI(t *testing.T) {
+	invalidInput := writeTempFile(t, "3\n09:00 19:00\n10\n09:41 1 client1\n09:54 7 client1\n09:55 1 cl@\n")
+
+	testCases := []struct {
+		name      string
+		args      []string
+		expCode   int
+		expStdout string
+		expStderr string
+	}{
+		{
+			name:      "no args",
+			expCode:   exitUsage,
+			expStderr: usage,
+		},
+		{
+			name:      "help",
+			args:      []string{"--help"},
+			expCode:   exitOK,
+			expStdout: usage,
+		},
+		{
+			name:      "unknown flag",
+			args:      []string{"-x"},
+			expCode:   exitUsage,
+			expStderr: "unknown command: -x\n" + usage,
+		},
+		{
+			name:      "run",
+			args:      []string{"run", exampleInput},
+			expCode:   exitOK,
+			expStdout: exampleOutput,
+		},
+		{
+			name:      "file only",
+			args:      []string{exampleInput},
+			expCode:   exitOK,
+			expStdout: exampleOutput,
+		},
+		{
+			name:    "run help",
+			args:    []string{"run", "--help"},
+			expCode: exitOK,
+		},
+		{
+			name:    "run without file",
+			args:    []string{"run"},
+			expCode: exitUsage,
+		},
+		{
+			name:    "run with extra args",
+			args:    []string{"run", exampleInput, "extra"},
+			expCode: exitUsage,
+		},
+		{
+			name:      "run missing file",
+			args:      []string{"run", "missing.txt"},
+			expCode:   exitFailure,
+			expStderr: "file does not exist: missing.txt\n",
+		},
+		{
+			name:      "run invalid input",
+			args:      []string{"run", invalidInput},
+			expCode:   exitInvalidInput,
+			expStderr: "validation error at row 5: unknown event type\n",
+		},
+		{
+			name:    "validate",
+			args:    []string{"validate", exampleInput},
+			expCode: exitOK,
+		},
+		{
+			name:    "validate invalid input",
+			args:    []string{"validate", invalidInput},
+			expCode: exitInvalidInput,
+			expStdout: "validation error at row 5: unknown event type\n" +
+				"validation error at row 6: invalid client name\n",
+		},
+		{
+			name:      "report",
+			args:      []string{"report", exampleInput},
+			expCode:   exitOK,
+			expStdout: "1 70 05:58\n2 30 02:18\n3 90 08:01\n",
+		},
+		{
+			name:    "report unknown format",
+			args:    []string{"report", "-format", "xml", exampleInput},
+			expCode: exitUsage,
+		},
+		{
+			name:    "state",
+			args:    []string{"state", "-at", "14:20", exampleInput},
+			expCode: exitOK,
+			expStdout: "14:20\ntables:\n1 client4 12:33\n3 client3 10:59\nqueue:\n" +
+				"clients:\nclient3 3\nclient4 1\nrevenue:\n1 50 04:26\n2 30 02:18\n3 40 03:21\nreservations:\n",
+		},
+		{
+			name:    "state without time",
+			args:    []string{"state", exampleInput},
+			expCode: exitUsage,
+		},
+		{
+			name:    "state not positive day",
+			args:    []string{"state", "-at", "14:20", "-day", "0", exampleInput},
+			expCode: exitUsage,
+		},
+		{
+			name:    "state unknown format",
+			args:    []string{"state", "-at", "14:20", "-format", "xml", exampleInput},
+			expCode: exitUsage,
+		},
+		{
+			name:    "simulate help",
+			args:    []string{"simulate", "-h"},
+			expCode: exitOK,
+		},
+		{
+			name:    "serve without file",
+			args:    []string{"serve"},
+			expCode: exitUsage,
+		},
+		{
+			name:      "serve invalid input",
+			args:      []string{"serve", invalidInput},
+			expCode:   exitInvalidInput,
+			expStderr: "validation error at row 5: unknown event type\n",
+		},
+		{
+			name:    "serve negative snapshot interval",
+			args:    []string{"serve", "-snapshot-every", "-1", "missing.txt"},
+			expCode: exitUsage,
+		},
+		{
+			name:    "serve invalid address",
+			args:    []string{"serve", "-addr", "invalid:address:1", exampleInput},
+			expCode: exitFailure,
+		},
+		{
+			name:    "simulate invalid tables",
+			args:    []string{"simulate", "-tables", "0"},
+			expCode: exitUsage,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			code, stdout, stderr := runCLI(tc.args...)
+			if code != tc.expCode {
+				t.Errorf("expected exit code %d, got %d, stderr: %s", tc.expCode, code, stderr)
+			}
+
+			if tc.expStdout != "" && stdout != tc.expStdout {
+				t.Errorf("expected stdout:\n%s\ngot:\n%s", tc.expStdout, stdout)
+			}
+
+			if tc.expStderr != "" && stderr != tc.expStderr {
+				t.Errorf("expected stderr:\n%s\ngot:\n%s", tc.expStderr, stderr)
+			}
+		})
+	}
+}
+
+func TestQueueConfig(t *testing.T) {
+	input := writeTempFile(t, "1\n09:00 19:00\n10\nmember member1\n"+
+		"09:01 1 client1\n09:01 1 client2\n09:01 1 member1\n"+
+		"09:02 2 client1 1\n09:03 3 client2\n09:03 3 member1\n09:04 4 client1\n")
+
 	testCases := []struct {
-		name        string
-		expectedArg string
-		args        []string
-		isErrExp    bool
+		name      string
+		env       map[string]string
+		expCode   int
+		expStdout string
+		expStderr string
 	}{
 		{
-			name:     "no file arg",
-			args:     []string{"exec"},
-			isErrExp: true,
+			name:      "default",
+			expStdout: "09:03 11 member1\n09:04 4 client1\n09:04 12 client2 1\n",
+		},
+		{
+			name:      "members go first",
+			env:       map[string]string{"QUEUE_POLICY": "priority", "MAX_QUEUE_LENGTH": "2"},
+			expStdout: "09:03 3 member1\n09:04 4 client1\n09:04 12 member1 1\n",
 		},
 		{
-			name:        "file arg",
-			expectedArg: "filename",
-			args:        []string{"exec", "filename"},
-			isErrExp:    false,
+			name:      "unknown policy",
+			env:       map[string]string{"QUEUE_POLICY": "lifo"},
+			expCode:   exitFailure,
+			expStderr: "checkout configuration: unknown queue policy: lifo\n",
 		},
 		{
-			name:        "file arg with extra args",
-			expectedArg: "filename",
-			args:        []string{"exec", "filename", "extra"},
-			isErrExp:    false,
+			name:      "negative max queue length",
+			env:       map[string]string{"MAX_QUEUE_LENGTH": "-1"},
+			expCode:   exitFailure,
+			expStderr: "checkout configuration: max queue length must not be negative: -1\n",
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			os.Args = tc.args
-			arg, err := parseArgs()
-			if err != nil && !tc.isErrExp {
-				t.Errorf("unexpected error: %s", err)
+			for key, value := range tc.env {
+				t.Setenv(key, value)
+			}
+
+			code, stdout, stderr := runCLI("run", input)
+			if code != tc.expCode {
+				t.Fatalf("expected exit code %d, got %d, stderr: %s", tc.expCode, code, stderr)
+			}
+
+			if !strings.Contains(stdout, tc.expStdout) {
+				t.Errorf("expected stdout to contain:\n%s\ngot:\n%s", tc.expStdout, stdout)
+			}
+
+			if tc.expStderr != "" && stderr != tc.expStderr {
+				t.Errorf("expected stderr:\n%s\ngot:\n%s", tc.expStderr, stderr)
+			}
+		})
+	}
+}
+
+func TestTimeFormatConfig(t *testing.T) {
+	input := writeTempFile(t, "1\n09:00:00 19:00:00\n10\n09:01:10 1 client1\n09:02:20 2 client1 1\n10:03:50 4 client1\n")
+
+	testCases := []struct {
+		name      string
+		env       map[string]string
+		expCode   int
+		expStdout string
+		expStderr string
+	}{
+		{
+			name:      "seconds",
+			env:       map[string]string{"TIME_FORMAT": "15:04:05"},
+			expStdout: "09:00:00\n09:01:10 1 client1\n09:02:20 2 client1 1\n10:03:50 4 client1\n19:00:00\n1 20 01:01:30\n",
+		},
+		{
+			name:      "output without seconds",
+			env:       map[string]string{"INPUT_TIME_FORMAT": "15:04:05", "OUTPUT_TIME_FORMAT": "15:04"},
+			expStdout: "09:00\n09:01 1 client1\n09:02 2 client1 1\n10:03 4 client1\n19:00\n1 20 01:01\n",
+		},
+		{
+			name:      "12-hour clock without period",
+			env:       map[string]string{"INPUT_TIME_FORMAT": "3:04:05"},
+			expCode:   exitFailure,
+			expStderr: "checkout configuration: input time format: \"3:04:05\" doesn't keep hours and minutes\n",
+		},
+		{
+			name:      "separator in time",
+			env:       map[string]string{"INPUT_TIME_FORMAT": "15 04"},
+			expCode:   exitFailure,
+			expStderr: "checkout configuration: input time format: \"15 04\" contains the separator \" \"\n",
+		},
+		{
+			name:      "date only",
+			env:       map[string]string{"OUTPUT_TIME_FORMAT": "2006-01-02"},
+			expCode:   exitFailure,
+			expStderr: "checkout configuration: output time format: \"2006-01-02\" doesn't keep hours and minutes\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for key, value := range tc.env {
+				t.Setenv(key, value)
+			}
+
+			code, stdout, stderr := runCLI("run", input)
+			if code != tc.expCode {
+				t.Fatalf("expected exit code %d, got %d, stderr: %s", tc.expCode, code, stderr)
+			}
+
+			if stdout != tc.expStdout {
+				t.Errorf("expected stdout:\n%s\ngot:\n%s", tc.expStdout, stdout)
+			}
+
+			if tc.expStderr != "" && stderr != tc.expStderr {
+				t.Errorf("expected stderr:\n%s\ngot:\n%s", tc.expStderr, stderr)
+			}
+		})
+	}
+}
+
+func TestRunCSV(t *testing.T) {
+	csvPath := filepath.Join(t.TempDir(), "report.csv")
+
+	code, _, stderr := runCLI("run", "-csv", csvPath, exampleInput)
+	if code != exitOK {
+		t.Fatalf("expected exit code %d, got %d, stderr: %s", exitOK, code, stderr)
+	}
+
+	content, err := os.ReadFile(csvPath)
+	if err != nil {
+		t.Fatalf("failed to read report: %s", err)
+	}
+
+	if !strings.HasPrefix(string(content), "day,table,category,income") {
+		t.Errorf("unexpected report:\n%s", content)
+	}
+}
+
+func TestReportJSON(t *testing.T) {
+	code, stdout, stderr := runCLI("report", "-format", "json", exampleInput)
+	if code != exitOK {
+		t.Fatalf("expected exit code %d, got %d, stderr: %s", exitOK, code, stderr)
+	}
+
+	var report struct {
+		Total struct {
+			Income int `json:"income"`
+		} `json:"total"`
+	}
+
+	if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+		t.Fatalf("invalid json: %s", err)
+	}
+
+	if report.Total.Income != 190 {
+		t.Errorf("expected total income 190, got %d", report.Total.Income)
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	generated := filepath.Join(t.TempDir(), "generated.txt")
+
+	code, _, stderr := runCLI("simulate", "-seed", "42", "-tables", "3", "-clients", "30", "-o", generated)
+	if code != exitOK {
+		t.Fatalf("expected exit code %d, got %d, stderr: %s", exitOK, code, stderr)
+	}
+
+	code, stdout, _ := runCLI("simulate", "-seed", "42", "-tables", "3", "-clients", "30")
+	content, err := os.ReadFile(generated)
+	if err != nil {
+		t.Fatalf("failed to read generated file: %s", err)
+	}
+
+	if code != exitOK || stdout != string(content) {
+		t.Errorf("generation with the same seed must be the same")
+	}
+
+	// working days over midnight and close to it make the clock times go round
+	workingTimes := [][2]string{{"09:00", "21:00"}, {"20:00", "02:00"}, {"00:30", "23:59"}, {"23:30", "20:30"}}
+	for _, workingTime := range workingTimes {
+		for seed := 1; seed <= 40; seed++ {
+			code, _, stderr = runCLI(
+				"simulate", "-seed", strconv.Itoa(seed), "-tables", "3", "-clients", "30",
+				"-open", workingTime[0], "-close", workingTime[1], "-o", generated,
+			)
+			if code != exitOK {
+				t.Fatalf("expected exit code %d, got %d, stderr: %s", exitOK, code, stderr)
+			}
+
+			for _, command := range []string{"validate", "run"} {
+				if code, _, stderr = runCLI(command, generated); code != exitOK {
+					t.Errorf("%s: generated file with seed %d and working time %v must be valid, got exit code %d, stderr: %s",
+						command, seed, workingTime, code, stderr)
+				}
+			}
+		}
+	}
+}
+
+func TestRunStdin(t *testing.T) {
+	input, err := os.ReadFile(exampleInput)
+	if err != nil {
+		t.Fatalf("failed to read input: %s", err)
+	}
+
+	for _, args := range [][]string{{"run", "-"}, {"-"}, {"run", "-follow", "-"}} {
+		code, stdout, stderr := runCLIWithInput(string(input), args...)
+		if code != exitOK {
+			t.Errorf("%v: expected exit code %d, got %d, stderr: %s", args, exitOK, code, stderr)
+		}
+
+		if stdout != exampleOutput {
+			t.Errorf("%v: expected stdout:\n%s\ngot:\n%s", args, exampleOutput, stdout)
+		}
+	}
+}
+
+func TestRunDays(t *testing.T) {
+	t.Setenv("OUTPUT_FORMAT", "json")
+
+	input := writeTempFile(t, "2\n20:00 02:00\n10\n21:00 1 client1\n21:00 2 client1 1\n01:00 4 client1\nday\n23:30 1 client2\n23:30 2 client2 2\n")
+	code, stdout, stderr := runCLI("run", input)
+	if code != exitOK {
+		t.Fatalf("expected exit code %d, got %d, stderr: %s", exitOK, code, stderr)
+	}
+
+	var incomes []int
+	decoder := json.NewDecoder(strings.NewReader(stdout))
+	for decoder.More() {
+		var day struct {
+			Revenue []struct {
+				Income int `json:"income"`
+			} `json:"revenue"`
+		}
+
+		if err := decoder.Decode(&day); err != nil {
+			t.Fatalf("invalid json: %s", err)
+		}
+
+		for _, revenue := range day.Revenue {
+			incomes = append(incomes, revenue.Income)
+		}
+	}
+
+	// every working day has its own document with the revenue of the day
+	if len(incomes) != 2 || incomes[0] != 40 || incomes[1] != 30 {
+		t.Errorf("expected incomes [40 30], got %v, stdout:\n%s", incomes, stdout)
+	}
+
+	t.Setenv("OUTPUT_FORMAT", "text")
+
+	// reports have the section of every day, not only of the last one
+	code, stdout, stderr = runCLI("report", input)
+	if code != exitOK {
+		t.Fatalf("expected exit code %d, got %d, stderr: %s", exitOK, code, stderr)
+	}
+
+	if exp := "1 40 04:00\nday\n2 30 02:30\n"; stdout != exp {
+		t.Errorf("expected report:\n%s\ngot:\n%s", exp, stdout)
+	}
+
+	// the moment is located on the given day, the earlier days are finished
+	code, stdout, stderr = runCLI("state", "-at", "23:45", "-day", "2", input)
+	if code != exitOK {
+		t.Fatalf("expected exit code %d, got %d, stderr: %s", exitOK, code, stderr)
+	}
+
+	exp := "23:45\ntables:\n2 client2 23:30\nqueue:\nclients:\nclient2 2\nrevenue:\n2 10 00:15\nreservations:\n"
+	if stdout != exp {
+		t.Errorf("expected state:\n%s\ngot:\n%s", exp, stdout)
+	}
+}
+
+func TestRunBalances(t *testing.T) {
+	balancesPath := filepath.Join(t.TempDir(), "balances.txt")
+
+	// the balance left after the first day is spent on the next one
+	input := writeTempFile(t, "2\n10:00 14:00\n10\nbalance client1 30\n"+
+		"10:05 1 client1\n10:05 2 client1 1\n11:00 4 client1\nday\n10:05 1 client1\n10:05 2 client1 1\n")
+	code, stdout, stderr := runCLI("run", "-balances", balancesPath, input)
+	if code != exitOK {
+		t.Fatalf("expected exit code %d, got %d, stderr: %s", exitOK, code, stderr)
+	}
+
+	if !strings.Contains(stdout, "12:05 14 client1\n") {
+		t.Errorf("expected client1 to leave out of balance, got:\n%s", stdout)
+	}
+
+	content, err := os.ReadFile(balancesPath)
+	if err != nil {
+		t.Fatalf("failed to read balances: %s", err)
+	}
+
+	if string(content) != "client1 30 30 0\n" {
+		t.Errorf("unexpected balances:\n%s", content)
+	}
+}
+
+// syncBuffer is a buffer, which can be read while the events are written.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func waitOutput(t *testing.T, out *syncBuffer, exp string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for out.String() != exp {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected output:\n%s\ngot:\n%s", exp, out.String())
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestFollowInput(t *testing.T) {
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("failed to load config: %s", err)
+	}
+
+	now := func() time.Time {
+		return time.Date(2023, 3, 1, 10, 0, 0, 0, time.Local)
+	}
+
+	t.Run("finished on cancel", func(t *testing.T) {
+		pr, pw := io.Pipe()
+		defer func() {
+			_ = pw.Close()
+		}()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		out := &syncBuffer{}
+		done := make(chan error, 1)
+		go func() {
+			_, e := followInput(ctx, pr, true, now, cfg, out)
+			done <- e
+		}()
+
+		_, _ = io.WriteString(pw, "2\n09:00 19:00\n10\n09:41 1 client1\n")
+		waitOutput(t, out, "09:00\n09:41 1 client1\n")
+
+		// events are printed immediately, without waiting for the end of the input
+		_, _ = io.WriteString(pw, "09:54 2 client1 1\n")
+		waitOutput(t, out, "09:00\n09:41 1 client1\n09:54 2 client1 1\n")
+
+		cancel()
+		if err = <-done; err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		waitOutput(t, out, "09:00\n09:41 1 client1\n09:54 2 client1 1\n19:00 11 client1\n19:00\n1 100 09:06\n")
+	})
+
+	t.Run("finished at closing time", func(t *testing.T) {
+		pr, pw := io.Pipe()
+		defer func() {
+			_ = pw.Close()
+		}()
+
+		closingSoon := func() time.Time {
+			return time.Date(2023, 3, 1, 18, 59, 59, 0, time.Local).Add(900 * time.Millisecond)
+		}
+
+		out := &syncBuffer{}
+		go func() {
+			_, _ = io.WriteString(pw, "1\n09:00 19:00\n10\n09:41 1 client1\n")
+		}()
+
+		if _, err = followInput(context.Background(), pr, true, closingSoon, cfg, out); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if out.String() != "09:00\n09:41 1 client1\n19:00 11 client1\n19:00\n" {
+			t.Errorf("unexpected output:\n%s", out.String())
+		}
+	})
+
+	t.Run("header only finished at closing time", func(t *testing.T) {
+		pr, pw := io.Pipe()
+		defer func() {
+			_ = pw.Close()
+		}()
+
+		closingSoon := func() time.Time {
+			return time.Date(2023, 3, 1, 18, 59, 59, 0, time.Local).Add(900 * time.Millisecond)
+		}
+
+		// the closing isn't delayed by the header waiting for the first event
+		out := &syncBuffer{}
+		go func() {
+			_, _ = io.WriteString(pw, "1\n09:00 19:00\n10\n")
+		}()
+
+		if _, err = followInput(context.Background(), pr, true, closingSoon, cfg, out); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if out.String() != "09:00\n19:00\n" {
+			t.Errorf("unexpected output:\n%s", out.String())
+		}
+	})
+}
+
+func TestServeSession(t *testing.T) {
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("failed to load config: %s", err)
+	}
+
+	in, err := os.Open(exampleInput)
+	if err != nil {
+		t.Fatalf("failed to open input: %s", err)
+	}
+
+	defer func() {
+		_ = in.Close()
+	}()
+
+	var out bytes.Buffer
+	session, _, err := newSession(in, cfg, &out, "", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	rec := httptest.NewRecorder()
+	session.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/close", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+	}
+
+	// events of the file and closed day give the same results as the run command
+	if out.String() != exampleOutput {
+		t.Errorf("expected output:\n%s\ngot:\n%s", exampleOutput, out.String())
+	}
+}
+
+func TestServeRestore(t *testing.T) {
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("failed to load config: %s", err)
+	}
+
+	header := writeTempFile(t, "3\n09:00 19:00\n10\n09:41 1 client1\n")
+
+	do := func(handler http.Handler, method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	// snapshot interval larger than the events count keeps the whole session in the journal
+	for _, snapshotInterval := range []int{1, 100} {
+		t.Run("snapshot every "+strconv.Itoa(snapshotInterval), func(t *testing.T) {
+			dataDir := t.TempDir()
+
+			start := func() (http.Handler, func()) {
+				in, err := os.Open(header)
+				if err != nil {
+					t.Fatalf("failed to open input: %s", err)
+				}
+
+				defer func() {
+					_ = in.Close()
+				}()
+
+				session, data, err := newSession(in, cfg, io.Discard, dataDir, snapshotInterval)
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+
+				return session.Handler(), func() {
+					if err := data.Close(); err != nil {
+						t.Errorf("failed to close the session data: %s", err)
+					}
+				}
 			}
 
-			if arg != tc.expectedArg {
-				t.Errorf("expected arg %s, got %s", tc.expectedArg, arg)
+			handler, stop := start()
+			rec := do(handler, http.MethodPost, "/events", `{"time":"09:54","type":2,"client":"client1","table":1}`)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+			}
+
+			// the session stops without closing the working day, as after a crash
+			stop()
+
+			// the state is kept in the file storages of the directory
+			tables, err := storage.NewFileStorage[int, *model.IncomingEvent](
+				filepath.Join(dataDir, "storage", "tables"), storage.JSONCodec[int]{}, storage.JSONCodec[*model.IncomingEvent]{},
+			)
+			if err != nil {
+				t.Fatalf("failed to open the tables storage: %s", err)
+			}
+
+			sitting, ok := tables.Get(1)
+			_ = tables.Close()
+			if !ok || sitting.Client.GetName() != "client1" {
+				t.Fatalf("expected client1 at table 1 in the storage, got %v", sitting)
+			}
+
+			// the restarted session has the events of the previous run, the events of the file aren't applied twice
+			handler, stop = start()
+
+			expState := `{"closed":false,"tables":[{"table":1,"client":"client1","since":"09:54"}],"queue":[],` +
+				`"clients":[{"name":"client1","table":1}],"reservations":[]}` + "\n"
+			if rec = do(handler, http.MethodGet, "/state", ""); rec.Body.String() != expState {
+				t.Errorf("expected state:\n%s\ngot:\n%s", expState, rec.Body)
+			}
+
+			rec = do(handler, http.MethodPost, "/events", `{"time":"09:50","type":1,"client":"client2"}`)
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("event before the restored ones must be rejected, got status %d", rec.Code)
+			}
+
+			if rec = do(handler, http.MethodPost, "/close", ""); rec.Code != http.StatusOK {
+				t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+			}
+
+			stop()
+
+			// the closed session is restored with the revenue counted once
+			handler, stop = start()
+			defer stop()
+
+			rec = do(handler, http.MethodGet, "/revenue", "")
+			for _, exp := range []string{`"income": 100`, `"usage_time": "09:06"`, `"sessions": 1`} {
+				if !strings.Contains(rec.Body.String(), exp) {
+					t.Errorf("expected %s in the revenue:\n%s", exp, rec.Body)
+				}
 			}
 		})
 	}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"yadro-intern/internal/report"
+)
+
+const (
+	reportFormatText = "text"
+	reportFormatJSON = "json"
+	reportFormatCSV  = "csv"
+)
+
+var reportCommand = &command{
+	name:        "report",
+	usage:       "report [-format text|json|csv] [-o <output>] <filename>",
+	description: "Processes events of the file and prints only the revenue of the tables.",
+	run:         (*cli).runReport,
+}
+
+func (c *cli) runReport(cmd *command, args []string) int {
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	format := fs.String("format", reportFormatText, "format of the report: text, json or csv")
+	outPath := fs.String("o", "-", "path of the report file, - for stdout")
+	if code, ok := c.parseFlags(cmd, fs, args, 1); !ok {
+		return code
+	}
+
+	var write func(out io.Writer, day *workingDay, timeFormat string) error
+	switch *format {
+	case reportFormatText:
+		write = func(out io.Writer, day *workingDay, timeFormat string) error {
+			return report.WriteTextDays(out, day.coreData, day.days, timeFormat)
+		}
+	case reportFormatJSON:
+		write = func(out io.Writer, day *workingDay, timeFormat string) error {
+			return report.WriteJSONDays(out, day.coreData, day.days, timeFormat)
+		}
+	case reportFormatCSV:
+		write = func(out io.Writer, day *workingDay, timeFormat string) error {
+			return report.WriteCSV(out, day.coreData, day.days, timeFormat)
+		}
+	default:
+		c.printf("unknown report format: %s\n", *format)
+		fs.Usage()
+		return exitUsage
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return c.fail(err)
+	}
+
+	day, err := processFile(fs.Arg(0), cfg, io.Discard)
+	if err != nil {
+		return c.fail(err)
+	}
+
+	if err = c.writeReport(*outPath, func(out io.Writer) error {
+		return write(out, day, cfg.processor.TimeFormat)
+	}); err != nil {
+		return c.fail(fmt.Errorf("report: %w", err))
+	}
+
+	return exitOK
+}
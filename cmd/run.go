@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	"yadro-intern/internal/follow"
+	"yadro-intern/internal/model"
+	"yadro-intern/internal/parser"
+	"yadro-intern/internal/processor"
+	"yadro-intern/internal/report"
+	"yadro-intern/internal/storage"
+)
+
+const runDescription = `Processes events of the file and prints the working day and the revenue of the tables.
+The filename "-" means stdin.
+
+With -follow the file is tailed as it grows, events are printed as soon as they are processed,
+the working day is finished at the closing time or on SIGINT/SIGTERM.
+
+With -receipts the itemized receipt of every client is written: a line for every session
+at the table with the usage and billed time and the amount, then the total.
+
+With -balances the balance of every client with the prepaid account is written:
+the balance at the opening, the charged amount and the balance at the closing.`
+
+var runCommand = &command{
+	name:        "run",
+	usage:       "run [-csv <report.csv>] [-receipts <receipts.txt>] [-balances <balances.txt>] [-follow] <filename>",
+	description: runDescription,
+	run:         (*cli).runRun,
+}
+
+func (c *cli) runRun(cmd *command, args []string) int {
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	csvPath := fs.String("csv", "", "path of the CSV report with revenue and usage of every table")
+	receiptsPath := fs.String("receipts", "", "path of the itemized receipts of the clients")
+	balancesPath := fs.String("balances", "", "path of the balances of the clients with the prepaid accounts")
+	followMode := fs.Bool("follow", false, "tail the growing file and print the events immediately")
+	if code, ok := c.parseFlags(cmd, fs, args, 1); !ok {
+		return code
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return c.fail(err)
+	}
+
+	in, err := c.openInput(fs.Arg(0))
+	if err != nil {
+		return c.fail(err)
+	}
+
+	defer func() {
+		_ = in.Close()
+	}()
+
+	var day *workingDay
+	if *followMode {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		// stdin can't grow after EOF, so it isn't tailed
+		day, err = followInput(ctx, in, fs.Arg(0) != "-", time.Now, cfg, c.stdout)
+	} else {
+		day, err = processInput(in, cfg, c.stdout)
+	}
+
+	if err != nil {
+		return c.fail(err)
+	}
+
+	if *csvPath != "" {
+		if err = c.writeReport(*csvPath, func(out io.Writer) error {
+			return report.WriteCSV(out, day.coreData, day.days, cfg.processor.TimeFormat)
+		}); err != nil {
+			return c.fail(err)
+		}
+	}
+
+	if *receiptsPath != "" {
+		if err = c.writeReport(*receiptsPath, func(out io.Writer) error {
+			return report.WriteReceiptsDays(out, day.days, cfg.processor.TimeFormat)
+		}); err != nil {
+			return c.fail(err)
+		}
+	}
+
+	if *balancesPath != "" {
+		if err = c.writeReport(*balancesPath, func(out io.Writer) error {
+			return report.WriteBalances(out, day.coreData, day.balances)
+		}); err != nil {
+			return c.fail(err)
+		}
+	}
+
+	return exitOK
+}
+
+// workingDay is the result of processing the file.
+type workingDay struct {
+	coreData *model.CoreData
+
+	// days are the revenue and the sessions of every working day of the file.
+	days []*processor.DayResult
+
+	// balances are the balances of the clients with the accounts after the last day.
+	balances []processor.BalanceState
+}
+
+// followPollInterval is the delay between reads of the followed file after EOF.
+const followPollInterval = 200 * time.Millisecond
+
+// processFile processes all events of the file and writes the results to out.
+//
+// If the file has a format error, nothing is written and the error is returned.
+func processFile(filename string, cfg *appConfig, out io.Writer) (*workingDay, error) {
+	f, err := openFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = f.Close()
+	}()
+
+	return processInput(f, cfg, out)
+}
+
+// processInput processes all events of the input and writes the results to out.
+//
+// If the input has a format error, nothing is written and the error is returned.
+func processInput(in io.Reader, cfg *appConfig, out io.Writer) (*workingDay, error) {
+	// in case of parallel processing:
+	//	by the task, we should print error, if it occurs
+	//  without any additional information, for that purpose
+	//  we can use buffer to store all successfully parsed events here
+	var temporaryBuffer = bytes.NewBuffer(nil)
+
+	day, err := processEvents(in, cfg, temporaryBuffer, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// no errors mean that all events are successfully processed,
+	// so we can print all successfully parsed events
+	if _, err = temporaryBuffer.WriteTo(out); err != nil {
+		return nil, fmt.Errorf("failed to write results: %s", err)
+	}
+
+	return day, nil
+}
+
+// followInput processes events as soon as they are written to the input,
+// the results are written to out without buffering.
+//
+// When tail is set, EOF of the input isn't final, like in "tail -f".
+// The working day is finished at the closing time or when ctx is done.
+func followInput(
+	ctx context.Context, in io.Reader, tail bool, now func() time.Time, cfg *appConfig, out io.Writer,
+) (*workingDay, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var closing *time.Timer
+	defer func() {
+		if closing != nil {
+			closing.Stop()
+		}
+	}()
+
+	return processEvents(follow.NewReader(ctx, in, tail, followPollInterval), cfg, out, func(coreData *model.CoreData) {
+		closing = time.AfterFunc(follow.UntilClosing(now(), coreData.WorkingTime), cancel)
+	})
+}
+
+// processEvents reads the input and processes its events, the results are written to out.
+//
+// onHeader, if set, is called after the first three lines of the header are read,
+// it doesn't wait for the directives, which end at the first line, which isn't a directive.
+func processEvents(
+	in io.Reader, cfg *appConfig, out io.Writer, onHeader func(coreData *model.CoreData),
+) (*workingDay, error) {
+	// reading core file data in synchronous way
+	// because all others operations depend on it
+	//
+	// can use fan-out pattern here, but still fine in sync way
+	fp := parser.NewFileParser(bufio.NewScanner(in), cfg.parser)
+	coreData, err := fp.ReadHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	if onHeader != nil {
+		onHeader(coreData)
+	}
+
+	if err = fp.ReadDirectives(coreData); err != nil {
+		return nil, err
+	}
+
+	// reading events are done in a separate goroutine
+	// made for making performance better?
+	// (probably not, in case of printing errors first)
+	eventsChan := fp.ReadEvents(coreData.TablesCount)
+
+	p := processor.NewEventProcessor(
+		out,
+		cfg.processor,
+		coreData,
+		cfg.billingPolicy,
+		storage.NewOrderedStorage[int, *model.IncomingEvent](storage.Less[int]),
+		storage.NewOrderedStorage[int, *model.RevenueStats](storage.Less[int]),
+		storage.NewOrderedStorage[string, int](storage.Less[string]),
+		processor.NewWaitingQueue(cfg.processor.QueuePolicy, coreData),
+	)
+
+	done := make(chan error)
+	defer close(done)
+
+	go func() {
+		e := p.ProcessEvents(eventsChan)
+		if e != nil {
+			done <- e
+		} else {
+			p.ShowRevenue()
+			done <- nil
+		}
+	}()
+
+	if err = <-done; err != nil {
+		return nil, err
+	}
+
+	return &workingDay{coreData: coreData, days: p.Days(), balances: p.Balances()}, nil
+}
+
+// writeReport writes the report to the file, "-" means stdout.
+func (c *cli) writeReport(filename string, write func(out io.Writer) error) error {
+	f, err := c.createFile(filename)
+	if err != nil {
+		return err
+	}
+
+	if err = write(f); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write report: %s", err)
+	}
+
+	return f.Close()
+}
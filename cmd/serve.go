@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+	"yadro-intern/internal/journal"
+	"yadro-intern/internal/model"
+	"yadro-intern/internal/parser"
+	"yadro-intern/internal/processor"
+	"yadro-intern/internal/server"
+	"yadro-intern/internal/storage"
+)
+
+const serveDescription = `Starts the live session of the computer club with the HTTP API for the events.
+The file has the header of the input and, optionally, the events happened before the start.
+
+  POST /events         {"time": "09:41", "type": 2, "client": "client1", "table": 1}
+  GET  /events/stream  Server-Sent Events of the written events, ?format=json for JSON data
+  GET  /state          busy tables, waiting queue and clients in the club
+  GET  /revenue        running revenue of the tables
+  POST /close          closes the working day
+
+The processing results are printed as in the run command,
+the working day is closed on SIGINT/SIGTERM, if it isn't closed yet.
+
+With -data the events are kept in the journal of the directory with periodic snapshots,
+the restarted session is restored from them instead of the events of the file.
+The tables, clients, revenue and waiting queue are kept in the file storages of the directory.`
+
+// shutdownTimeout is the time given to the requests in progress, when the server stops.
+const shutdownTimeout = 5 * time.Second
+
+var serveCommand = &command{
+	name:        "serve",
+	usage:       "serve [-addr <address>] [-data <dir>] [-snapshot-every N] <filename>",
+	description: serveDescription,
+	run:         (*cli).runServe,
+}
+
+func (c *cli) runServe(cmd *command, args []string) int {
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	addr := fs.String("addr", ":8080", "address of the HTTP API")
+	dataDir := fs.String("data", "", "directory of the journal and snapshots, the session isn't kept, if empty")
+	snapshotEvery := fs.Int("snapshot-every", 100, "count of the events between snapshots, 0 for no snapshots")
+	if code, ok := c.parseFlags(cmd, fs, args, 1); !ok {
+		return code
+	}
+
+	if *snapshotEvery < 0 {
+		c.printf("snapshot interval can't be negative: %d\n", *snapshotEvery)
+		fs.Usage()
+		return exitUsage
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return c.fail(err)
+	}
+
+	in, err := c.openInput(fs.Arg(0))
+	if err != nil {
+		return c.fail(err)
+	}
+
+	session, data, err := newSession(in, cfg, c.stdout, *dataDir, *snapshotEvery)
+	_ = in.Close()
+	if err != nil {
+		return c.fail(err)
+	}
+
+	if data != nil {
+		defer func() {
+			_ = data.Close()
+		}()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           session.Handler(),
+		ReadHeaderTimeout: shutdownTimeout,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err = <-serveErr:
+		return c.fail(fmt.Errorf("failed to serve: %s", err))
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err = srv.Shutdown(shutdownCtx); err != nil {
+		return c.fail(fmt.Errorf("failed to stop the server: %s", err))
+	}
+
+	if _, err = session.Close(); err != nil && !errors.Is(err, server.ErrDayClosed) {
+		return c.fail(err)
+	}
+
+	return exitOK
+}
+
+// sessionData is the journal and the storages of the session kept in the data directory.
+type sessionData struct {
+	recorder *journal.Recorder
+	storages []io.Closer
+}
+
+// Close closes the journal and the storages, the first error is returned.
+func (d *sessionData) Close() error {
+	var err error
+	if d.recorder != nil {
+		err = d.recorder.Close()
+	}
+
+	for _, s := range d.storages {
+		if closeErr := s.Close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	return err
+}
+
+// processorStorages are the storages of the processor state.
+type processorStorages struct {
+	tables       storage.Storage[int, *model.IncomingEvent]
+	revenue      storage.Storage[int, *model.RevenueStats]
+	clients      storage.Storage[string, int]
+	waitingQueue storage.Queue[model.ClientData]
+}
+
+// newSession starts the live session with the header and the events of the input.
+//
+// If dataDir is set, the storages of the processor are kept in the directory,
+// the session is restored from the journal of the directory and keeps its events there,
+// the returned data must be closed.
+func newSession(
+	in io.Reader, cfg *appConfig, out io.Writer, dataDir string, snapshotInterval int,
+) (*server.Server, *sessionData, error) {
+	fp := parser.NewFileParser(bufio.NewScanner(in), cfg.parser)
+	coreData, err := fp.ReadCoreData()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	storages := &processorStorages{
+		tables:       storage.NewOrderedStorage[int, *model.IncomingEvent](storage.Less[int]),
+		revenue:      storage.NewOrderedStorage[int, *model.RevenueStats](storage.Less[int]),
+		clients:      storage.NewOrderedStorage[string, int](storage.Less[string]),
+		waitingQueue: processor.NewWaitingQueue(cfg.processor.QueuePolicy, coreData),
+	}
+
+	var data *sessionData
+	if dataDir != "" {
+		data = &sessionData{}
+		if err = openStorages(filepath.Join(dataDir, "storage"), cfg, storages, data); err != nil {
+			return nil, nil, closeData(data, err)
+		}
+	}
+
+	p := processor.NewEventProcessor(
+		out,
+		cfg.processor,
+		coreData,
+		cfg.billingPolicy,
+		storages.tables,
+		storages.revenue,
+		storages.clients,
+		storages.waitingQueue,
+	)
+
+	session := server.New(p, coreData, cfg.parser.TimeFormat, cfg.processor.TimeFormat)
+
+	if data != nil {
+		var restored *journal.Restored
+		data.recorder, restored, err = journal.Open(dataDir, p, coreData.TablesCount, snapshotInterval)
+		if err != nil {
+			return nil, nil, closeData(data, err)
+		}
+
+		session.Record(data.recorder, restored)
+
+		// events of the file are kept in the journal since the first start
+		if restored.Records > 0 {
+			return session, data, nil
+		}
+	}
+
+	for wrapped := range fp.ReadEvents(coreData.TablesCount) {
+		if wrapped.Err != nil {
+			return nil, nil, closeData(data, wrapped.Err)
+		}
+
+		if _, err = session.Apply(wrapped.Event); err != nil {
+			return nil, nil, closeData(data, err)
+		}
+	}
+
+	return session, data, nil
+}
+
+// openStorages replaces the storages with the file storages of the directory, they are added to the data.
+//
+// The journal restores the storages from the snapshot or, without it, replays the session
+// from the empty state, so the kept state isn't applied twice.
+// The waiting queue of the priority policy is kept in memory, the journal restores it.
+func openStorages(dir string, cfg *appConfig, storages *processorStorages, data *sessionData) error {
+	tables, err := storage.NewFileStorage[int, *model.IncomingEvent](
+		filepath.Join(dir, "tables"), storage.JSONCodec[int]{}, storage.JSONCodec[*model.IncomingEvent]{},
+	)
+	if err != nil {
+		return err
+	}
+
+	data.storages = append(data.storages, tables)
+	storages.tables = tables
+
+	revenue, err := storage.NewFileStorage[int, *model.RevenueStats](
+		filepath.Join(dir, "revenue"), storage.JSONCodec[int]{}, storage.JSONCodec[*model.RevenueStats]{},
+	)
+	if err != nil {
+		return err
+	}
+
+	data.storages = append(data.storages, revenue)
+	storages.revenue = revenue
+
+	clients, err := storage.NewFileStorage[string, int](
+		filepath.Join(dir, "clients"), storage.JSONCodec[string]{}, storage.JSONCodec[int]{},
+	)
+	if err != nil {
+		return err
+	}
+
+	data.storages = append(data.storages, clients)
+	storages.clients = clients
+
+	if cfg.processor.QueuePolicy == processor.QueuePriority {
+		return nil
+	}
+
+	waitingQueue, err := storage.NewFileQueue[model.ClientData](
+		filepath.Join(dir, "queue"),
+		storage.FuncCodec[model.ClientData]{EncodeFn: model.MarshalClientData, DecodeFn: model.UnmarshalClientData},
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+
+	data.storages = append(data.storages, waitingQueue)
+	storages.waitingQueue = waitingQueue
+	return nil
+}
+
+// closeData closes the data of the session, if it's kept, and returns the error caused the closing.
+func closeData(data *sessionData, err error) error {
+	if data != nil {
+		_ = data.Close()
+	}
+
+	return err
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"time"
+	"yadro-intern/internal/model"
+	"yadro-intern/internal/simulator"
+)
+
+var simulateCommand = &command{
+	name:        "simulate",
+	usage:       "simulate [-tables N] [-clients N] [-price N] [-open HH:MM] [-close HH:MM] [-seed N] [-o <output>]",
+	description: "Generates a random working day of the club in the input file format.",
+	run:         (*cli).runSimulate,
+}
+
+func (c *cli) runSimulate(cmd *command, args []string) int {
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	tables := fs.Int("tables", 5, "count of tables")
+	clients := fs.Int("clients", 20, "count of clients")
+	price := fs.Int("price", 10, "price per hour")
+	openAt := fs.String("open", "09:00", "opening time")
+	closeAt := fs.String("close", "21:00", "closing time")
+	seed := fs.Int64("seed", 0, "seed of the random generator, 0 for the current time")
+	outPath := fs.String("o", "-", "path of the generated file, - for stdout")
+	if code, ok := c.parseFlags(cmd, fs, args, 0); !ok {
+		return code
+	}
+
+	if *tables <= 0 || *clients < 0 || *price <= 0 {
+		c.printf("tables and price must be positive, clients can't be negative\n")
+		return exitUsage
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return c.fail(err)
+	}
+
+	start, err := time.Parse(cfg.parser.TimeFormat, *openAt)
+	if err != nil {
+		c.printf("invalid opening time: %s\n", *openAt)
+		return exitUsage
+	}
+
+	end, err := time.Parse(cfg.parser.TimeFormat, *closeAt)
+	if err != nil {
+		c.printf("invalid closing time: %s\n", *closeAt)
+		return exitUsage
+	}
+
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+
+	opts := &simulator.Options{
+		TablesCount:  *tables,
+		ClientsCount: *clients,
+		PricePerHour: *price,
+		WorkingTime:  model.NewTimeInterval(start, end),
+	}
+
+	// #nosec G404 -- generated input doesn't need a secure random
+	rnd := rand.New(rand.NewSource(*seed))
+	if err = c.writeReport(*outPath, func(out io.Writer) error {
+		return simulator.Generate(out, opts, rnd, cfg.parser.TimeFormat)
+	}); err != nil {
+		return c.fail(fmt.Errorf("simulate: %w", err))
+	}
+
+	return exitOK
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+	"yadro-intern/internal/model"
+	"yadro-intern/internal/parser"
+	"yadro-intern/internal/processor"
+	"yadro-intern/internal/report"
+	"yadro-intern/internal/storage"
+)
+
+const stateDescription = `Replays events of the file up to the moment and prints the state of the club:
+busy tables with their clients, the waiting queue, clients in the club
+and the revenue including the sessions in progress.
+
+With -day the moment is on the working day with that number of the multi-day input, the first one by default.`
+
+var stateCommand = &command{
+	name:        "state",
+	usage:       "state -at <time> [-day N] [-format text|json] <filename>",
+	description: stateDescription,
+	run:         (*cli).runState,
+}
+
+func (c *cli) runState(cmd *command, args []string) int {
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	atStr := fs.String("at", "", "moment of the state in the TIME_FORMAT, for example, 14:20")
+	day := fs.Int("day", 1, "number of the working day of the moment, starting from 1")
+	format := fs.String("format", "", "format of the state: text or json (default OUTPUT_FORMAT)")
+	if code, ok := c.parseFlags(cmd, fs, args, 1); !ok {
+		return code
+	}
+
+	if *day < 1 {
+		c.printf("day must be positive: %d\n", *day)
+		fs.Usage()
+		return exitUsage
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return c.fail(err)
+	}
+
+	at, err := time.Parse(cfg.parser.TimeFormat, *atStr)
+	if err != nil {
+		c.printf("invalid time: %q\n", *atStr)
+		fs.Usage()
+		return exitUsage
+	}
+
+	if *format == "" {
+		*format = cfg.processor.OutputFormat
+	}
+
+	var write func(out io.Writer, state *processor.State, at time.Time, timeFormat string) error
+	switch *format {
+	case reportFormatText:
+		write = report.WriteStateText
+	case reportFormatJSON:
+		write = report.WriteStateJSON
+	default:
+		c.printf("unknown state format: %s\n", *format)
+		fs.Usage()
+		return exitUsage
+	}
+
+	in, err := c.openInput(fs.Arg(0))
+	if err != nil {
+		return c.fail(err)
+	}
+
+	defer func() {
+		_ = in.Close()
+	}()
+
+	state, err := stateAt(in, cfg, *day-1, at)
+	if err != nil {
+		return c.fail(err)
+	}
+
+	if err = write(c.stdout, state, at, cfg.processor.TimeFormat); err != nil {
+		return c.fail(fmt.Errorf("failed to write state: %s", err))
+	}
+
+	return exitOK
+}
+
+// stateAt replays events of the input up to the moment on the working day and returns the state of the club,
+// the day is counted from 0.
+func stateAt(in io.Reader, cfg *appConfig, day int, at time.Time) (*processor.State, error) {
+	fp := parser.NewFileParser(bufio.NewScanner(in), cfg.parser)
+	coreData, err := fp.ReadCoreData()
+	if err != nil {
+		return nil, err
+	}
+
+	p := processor.NewEventProcessor(
+		io.Discard,
+		cfg.processor,
+		coreData,
+		cfg.billingPolicy,
+		storage.NewOrderedStorage[int, *model.IncomingEvent](storage.Less[int]),
+		storage.NewOrderedStorage[int, *model.RevenueStats](storage.Less[int]),
+		storage.NewOrderedStorage[string, int](storage.Less[string]),
+		processor.NewWaitingQueue(cfg.processor.QueuePolicy, coreData),
+	)
+
+	return p.StateAt(fp.ReadEvents(coreData.TablesCount), coreData.WorkingTime.AddDays(day).Locate(at))
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"yadro-intern/internal/output"
+	"yadro-intern/internal/parser"
+)
+
+var validateCommand = &command{
+	name:        "validate",
+	usage:       "validate [-format text|json] <filename>",
+	description: "Reports all format errors of the file with their row numbers, events aren't processed.",
+	run:         (*cli).runValidate,
+}
+
+func (c *cli) runValidate(cmd *command, args []string) int {
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	format := fs.String("format", "", "format of the errors: text or json (default OUTPUT_FORMAT)")
+	if code, ok := c.parseFlags(cmd, fs, args, 1); !ok {
+		return code
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return c.fail(err)
+	}
+
+	if *format == "" {
+		*format = cfg.processor.OutputFormat
+	}
+
+	f, err := openFile(fs.Arg(0))
+	if err != nil {
+		return c.fail(err)
+	}
+
+	defer func() {
+		_ = f.Close()
+	}()
+
+	errs := parser.NewFileParser(bufio.NewScanner(f), cfg.parser).Validate()
+	if err = output.WriteErrors(c.stdout, *format, errs); err != nil {
+		return c.fail(err)
+	}
+
+	if len(errs) > 0 {
+		return exitInvalidInput
+	}
+
+	return exitOK
+}
@@ -7,6 +7,32 @@ const (
 	ErrPricePerHourNotSpecified  = "price per hour are not specified"
 	ErrPricePerHourInvalidFormat = "price per hour are not integer"
 
+	ErrTableCategoryInvalidFormat  = "table category must be in format: category <name> <price> <tables>"
+	ErrTableCategoryInvalidName    = "invalid table category name"
+	ErrTableCategoryDuplicated     = "table category is already declared"
+	ErrFailedToParseCategoryPrice  = "failed to parse table category price"
+	ErrFailedToParseCategoryTables = "failed to parse table category tables"
+	ErrTableAlreadyCategorized     = "table already belongs to another category"
+
+	ErrTariffInvalidFormat      = "tariff must be in format: tariff <from> <to> <price> [category]"
+	ErrFailedToParseTariffTime  = "failed to parse tariff time"
+	ErrTariffEmptyInterval      = "tariff start and end must differ"
+	ErrFailedToParseTariffPrice = "failed to parse tariff price"
+	ErrTariffUnknownCategory    = "tariff category is not declared"
+	ErrTariffOverlaps           = "tariff overlaps with another tariff"
+
+	ErrMemberInvalidFormat = "member must be in format: member <name> [<name>...]"
+	ErrMemberDuplicated    = "client is already declared as a member"
+
+	ErrBalanceInvalidFormat = "balance must be in format: balance <client> <amount>"
+	ErrFailedToParseBalance = "failed to parse balance amount"
+	ErrBalanceDuplicated    = "client balance is already declared"
+
+	ErrDateInvalidFormat = "date must be in format: date <YYYY-MM-DD> [timezone]"
+	ErrFailedToParseDate = "failed to parse date"
+	ErrUnknownTimezone   = "unknown timezone"
+	ErrDateDuplicated    = "date is already declared"
+
 	ErrWorkingTimeNotSpecified  = "working time are not specified"
 	ErrWorkingTimeInvalidFormat = "working time are not time interval"
 	ErrFailedToParseStartTime   = "failed to parse start time"
@@ -14,11 +40,14 @@ const (
 
 	ErrEventInvalidFormat             = "event must be in format: <time> <event-type> <client-data>"
 	ErrFailedToParseEventTime         = "failed to parse event happened time"
+	ErrEventTimeBeforePrevious        = "event happened before the previous one"
 	ErrFailedToParseEventType         = "failed to parse event type"
 	ErrUnknownEventType               = "unknown event type"
 	ErrClientDataInvalidFormat        = "invalid client data format for event type"
 	ErrClientDataInvalidName          = "invalid client name"
 	ErrFailedToParseClientTableNumber = "failed to parse client table number"
+	ErrFailedToParseReservationTime   = "failed to parse reservation time"
+	ErrReservationEmptySlot           = "reservation start and end must differ"
 
 	ErrValueMustBeMoreThanZero = "value must be more than zero"
 	ErrValueTooBig             = "value is too big"
@@ -16,4 +16,13 @@ const (
 
 	// ErrCantWaitLonger is generated when the client tries to wait for a table, but some tables are free.
 	ErrCantWaitLonger = "ICanWaitNoLonger!"
+
+	// ErrTableIsReserved is generated when the client tries to sit on the table reserved by another client.
+	ErrTableIsReserved = "TableIsReserved"
+
+	// ErrReservationOverlaps is generated when the reserved time of the table overlaps another reservation.
+	ErrReservationOverlaps = "ReservationOverlaps"
+
+	// ErrReservationOutOfHours is generated when the reserved time isn't in the working time or has already passed.
+	ErrReservationOutOfHours = "ReservationOutOfHours"
 )
@@ -38,6 +38,15 @@ func ValidateName(name string) error {
 	return nil
 }
 
+func ValidateCategoryName(name string) error {
+	rgx := regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	if !rgx.MatchString(name) {
+		return errors.New(ErrTableCategoryInvalidName)
+	}
+
+	return nil
+}
+
 func (e *ValidationError) Error() string {
 	return fmt.Sprintf("validation error at row %d: %s", e.RowNumber, e.UserMsg)
 }
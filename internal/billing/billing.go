@@ -0,0 +1,171 @@
+package billing
+
+import (
+	"fmt"
+	"math"
+	"time"
+	"yadro-intern/cmd/config"
+)
+
+const (
+	PolicyHourly    = "hourly"
+	PolicyPerMinute = "per-minute"
+	PolicyBlocks    = "blocks"
+)
+
+// amountEpsilon hides floating point errors of the not rounded cost,
+// otherwise a cost like 20.000000000000004 is rounded up to 21.
+const amountEpsilon = 1e-9
+
+// Rate prices the usage of a table.
+type Rate interface {
+
+	// Cost returns the price of using a table for d since the session start, it isn't rounded.
+	//
+	// Policies round the session time before asking the rate, so the rounding is applied
+	// to the whole session, even if the price changes in the middle of it.
+	Cost(d time.Duration) float64
+}
+
+// HourlyRate is a fixed price of an hour of usage.
+type HourlyRate int
+
+func (r HourlyRate) Cost(d time.Duration) float64 {
+	return float64(r) * d.Hours()
+}
+
+// Policy decides how much a client pays for using a table.
+type Policy interface {
+
+	// Charge returns the amount a client pays for using a table for usage time.
+	Charge(usage time.Duration, rate Rate) int
+
+	// Billed returns the part of the usage time a client pays for, after the rounding.
+	Billed(usage time.Duration) time.Duration
+}
+
+// NewPolicy builds the policy described by the configuration.
+//
+// Grace period and minimal charge are applied on top of the chosen rounding,
+// the grace period goes first, so short sessions are free even with a minimal charge.
+func NewPolicy(cfg *config.Billing) (Policy, error) {
+	var policy Policy
+	switch cfg.Policy {
+	case PolicyHourly:
+		policy = NewHourly()
+	case PolicyPerMinute:
+		policy = NewPerMinute()
+	case PolicyBlocks:
+		if cfg.BlockSize <= 0 {
+			return nil, fmt.Errorf("billing block size must be positive, got %s", cfg.BlockSize)
+		}
+
+		policy = NewBlocks(cfg.BlockSize)
+	default:
+		return nil, fmt.Errorf("unknown billing policy: %s", cfg.Policy)
+	}
+
+	if cfg.MinCharge < 0 {
+		return nil, fmt.Errorf("billing minimal charge can't be negative, got %d", cfg.MinCharge)
+	}
+
+	if cfg.MinCharge > 0 {
+		policy = NewMinCharge(cfg.MinCharge, policy)
+	}
+
+	if cfg.GracePeriod < 0 {
+		return nil, fmt.Errorf("billing grace period can't be negative, got %s", cfg.GracePeriod)
+	}
+
+	if cfg.GracePeriod > 0 {
+		policy = NewGracePeriod(cfg.GracePeriod, policy)
+	}
+
+	return policy, nil
+}
+
+// Blocks rounds the usage time up to the whole number of blocks.
+type Blocks struct {
+	size time.Duration
+}
+
+func NewBlocks(size time.Duration) *Blocks {
+	return &Blocks{size: size}
+}
+
+// NewHourly is the default policy: even a few minutes at the table cost a whole hour.
+func NewHourly() *Blocks {
+	return NewBlocks(time.Hour)
+}
+
+func NewPerMinute() *Blocks {
+	return NewBlocks(time.Minute)
+}
+
+func (b *Blocks) Charge(usage time.Duration, rate Rate) int {
+	return roundAmount(rate.Cost(b.Billed(usage)))
+}
+
+func (b *Blocks) Billed(usage time.Duration) time.Duration {
+	blocks := usage / b.size
+	if usage%b.size != 0 {
+		blocks++
+	}
+
+	return blocks * b.size
+}
+
+// GracePeriod makes the first minutes of the session free,
+// the rest of the session is charged by the next policy.
+type GracePeriod struct {
+	free time.Duration
+	next Policy
+}
+
+func NewGracePeriod(free time.Duration, next Policy) *GracePeriod {
+	return &GracePeriod{free: free, next: next}
+}
+
+func (g *GracePeriod) Charge(usage time.Duration, rate Rate) int {
+	if usage <= g.free {
+		return 0
+	}
+
+	return g.next.Charge(usage-g.free, shiftedRate{rate: rate, offset: g.free})
+}
+
+func (g *GracePeriod) Billed(usage time.Duration) time.Duration {
+	if usage <= g.free {
+		return 0
+	}
+
+	return g.next.Billed(usage - g.free)
+}
+
+// MinCharge makes a client pay at least minAmount for the session.
+type MinCharge struct {
+	minAmount int
+	next      Policy
+}
+
+func NewMinCharge(minAmount int, next Policy) *MinCharge {
+	return &MinCharge{minAmount: minAmount, next: next}
+}
+
+func (m *MinCharge) Charge(usage time.Duration, rate Rate) int {
+	amount := m.next.Charge(usage, rate)
+	if amount < m.minAmount {
+		return m.minAmount
+	}
+
+	return amount
+}
+
+func (m *MinCharge) Billed(usage time.Duration) time.Duration {
+	return m.next.Billed(usage)
+}
+
+// roundAmount rounds the cost up, the club doesn't give change.
+func roundAmount(cost float64) int {
+	return int(math.Ceil(cost - amountEpsilon))
+}
@@ -0,0 +1,299 @@
+package billing
+
+import (
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+	"yadro-intern/cmd/config"
+	"yadro-intern/internal/model"
+)
+
+type billingSuite struct {
+	suite.Suite
+}
+
+func TestBillingSuite(t *testing.T) {
+	suite.Run(t, new(billingSuite))
+}
+
+func (s *billingSuite) TestCharge() {
+	testCases := []struct {
+		name   string
+		policy Policy
+		usage  time.Duration
+		rate   Rate
+		exp    int
+	}{
+		{
+			name:   "hourly, few minutes",
+			policy: NewHourly(),
+			usage:  5 * time.Minute,
+			rate:   HourlyRate(10),
+			exp:    10,
+		},
+		{
+			name:   "hourly, exact hours",
+			policy: NewHourly(),
+			usage:  2 * time.Hour,
+			rate:   HourlyRate(10),
+			exp:    20,
+		},
+		{
+			name:   "hourly, no usage",
+			policy: NewHourly(),
+			rate:   HourlyRate(10),
+			exp:    0,
+		},
+		{
+			name:   "per minute",
+			policy: NewPerMinute(),
+			usage:  90 * time.Minute,
+			rate:   HourlyRate(10),
+			exp:    15,
+		},
+		{
+			name:   "per minute, amount is rounded up",
+			policy: NewPerMinute(),
+			usage:  7 * time.Minute,
+			rate:   HourlyRate(10),
+			exp:    2,
+		},
+		{
+			name:   "per minute, started minute is charged",
+			policy: NewPerMinute(),
+			usage:  6*time.Minute + time.Second,
+			rate:   HourlyRate(60),
+			exp:    7,
+		},
+		{
+			name:   "quarter blocks",
+			policy: NewBlocks(15 * time.Minute),
+			usage:  31 * time.Minute,
+			rate:   HourlyRate(20),
+			exp:    15,
+		},
+		{
+			name:   "half hour blocks",
+			policy: NewBlocks(30 * time.Minute),
+			usage:  30 * time.Minute,
+			rate:   HourlyRate(20),
+			exp:    10,
+		},
+		{
+			name:   "grace period, free session",
+			policy: NewGracePeriod(10*time.Minute, NewHourly()),
+			usage:  10 * time.Minute,
+			rate:   HourlyRate(10),
+			exp:    0,
+		},
+		{
+			name:   "grace period, charged after it",
+			policy: NewGracePeriod(10*time.Minute, NewHourly()),
+			usage:  70 * time.Minute,
+			rate:   HourlyRate(10),
+			exp:    10,
+		},
+		{
+			name:   "min charge",
+			policy: NewMinCharge(25, NewPerMinute()),
+			usage:  30 * time.Minute,
+			rate:   HourlyRate(10),
+			exp:    25,
+		},
+		{
+			name:   "min charge, exceeded",
+			policy: NewMinCharge(25, NewPerMinute()),
+			usage:  3 * time.Hour,
+			rate:   HourlyRate(10),
+			exp:    30,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.Equal(tc.exp, tc.policy.Charge(tc.usage, tc.rate))
+		})
+	}
+}
+
+func (s *billingSuite) TestNewPolicy() {
+	testCases := []struct {
+		name     string
+		cfg      *config.Billing
+		usage    time.Duration
+		exp      int
+		isErrExp bool
+	}{
+		{
+			name:  "hourly",
+			cfg:   &config.Billing{Policy: PolicyHourly},
+			usage: 61 * time.Minute,
+			exp:   20,
+		},
+		{
+			name:  "per minute",
+			cfg:   &config.Billing{Policy: PolicyPerMinute},
+			usage: 61 * time.Minute,
+			exp:   11,
+		},
+		{
+			name:  "blocks",
+			cfg:   &config.Billing{Policy: PolicyBlocks, BlockSize: 30 * time.Minute},
+			usage: 61 * time.Minute,
+			exp:   15,
+		},
+		{
+			name:  "grace period goes before min charge",
+			cfg:   &config.Billing{Policy: PolicyHourly, GracePeriod: 5 * time.Minute, MinCharge: 100},
+			usage: 5 * time.Minute,
+			exp:   0,
+		},
+		{
+			name:  "min charge after grace period",
+			cfg:   &config.Billing{Policy: PolicyHourly, GracePeriod: 5 * time.Minute, MinCharge: 100},
+			usage: 6 * time.Minute,
+			exp:   100,
+		},
+		{
+			name:     "unknown policy",
+			cfg:      &config.Billing{Policy: "daily"},
+			isErrExp: true,
+		},
+		{
+			name:     "zero block size",
+			cfg:      &config.Billing{Policy: PolicyBlocks},
+			isErrExp: true,
+		},
+		{
+			name:     "negative min charge",
+			cfg:      &config.Billing{Policy: PolicyHourly, MinCharge: -1},
+			isErrExp: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			policy, err := NewPolicy(tc.cfg)
+			if tc.isErrExp {
+				s.Error(err)
+				return
+			}
+
+			s.NoError(err)
+			s.Equal(tc.exp, policy.Charge(tc.usage, HourlyRate(10)))
+		})
+	}
+}
+
+func (s *billingSuite) TestBilled() {
+	testCases := []struct {
+		name   string
+		policy Policy
+		usage  time.Duration
+		exp    time.Duration
+	}{
+		{
+			name:   "hourly",
+			policy: NewHourly(),
+			usage:  61 * time.Minute,
+			exp:    2 * time.Hour,
+		},
+		{
+			name:   "per minute",
+			policy: NewPerMinute(),
+			usage:  61*time.Minute + time.Second,
+			exp:    62 * time.Minute,
+		},
+		{
+			name:   "grace period isn't billed",
+			policy: NewGracePeriod(10*time.Minute, NewHourly()),
+			usage:  70 * time.Minute,
+			exp:    time.Hour,
+		},
+		{
+			name:   "session inside grace period",
+			policy: NewGracePeriod(10*time.Minute, NewMinCharge(5, NewHourly())),
+			usage:  5 * time.Minute,
+			exp:    0,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.Equal(tc.exp, tc.policy.Billed(tc.usage))
+		})
+	}
+}
+
+func (s *billingSuite) TestTariffRate() {
+	clock := func(hour, minute int) time.Time {
+		return time.Date(0, 0, 0, hour, minute, 0, 0, time.UTC)
+	}
+
+	evening := model.NewTariff(clock(12, 0), clock(23, 0), 20, "")
+	night := model.NewTariff(clock(22, 0), clock(2, 0), 30, "")
+
+	testCases := []struct {
+		name    string
+		policy  Policy
+		tariffs []*model.Tariff
+		from    time.Time
+		usage   time.Duration
+		exp     int
+	}{
+		{
+			name:   "no tariffs",
+			policy: NewHourly(),
+			from:   clock(10, 30),
+			usage:  100 * time.Minute,
+			exp:    20,
+		},
+		{
+			name:    "session crosses tariff, rounding of the whole session",
+			policy:  NewHourly(),
+			tariffs: []*model.Tariff{evening},
+			from:    clock(10, 30),
+			usage:   100 * time.Minute,
+			exp:     25,
+		},
+		{
+			name:    "session inside tariff",
+			policy:  NewHourly(),
+			tariffs: []*model.Tariff{evening},
+			from:    clock(13, 0),
+			usage:   30 * time.Minute,
+			exp:     20,
+		},
+		{
+			name:    "tariff over midnight",
+			policy:  NewHourly(),
+			tariffs: []*model.Tariff{night},
+			from:    clock(21, 0),
+			usage:   6 * time.Hour,
+			exp:     140,
+		},
+		{
+			name:    "per minute over tariff boundary",
+			policy:  NewPerMinute(),
+			tariffs: []*model.Tariff{evening},
+			from:    clock(11, 45),
+			usage:   30 * time.Minute,
+			exp:     8,
+		},
+		{
+			name:    "grace period shifts the session start",
+			policy:  NewGracePeriod(30*time.Minute, NewPerMinute()),
+			tariffs: []*model.Tariff{evening},
+			from:    clock(11, 30),
+			usage:   90 * time.Minute,
+			exp:     20,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			rate := NewTariffRate(10, tc.tariffs, tc.from)
+			s.Equal(tc.exp, tc.policy.Charge(tc.usage, rate))
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package billing
+
+import (
+	"time"
+	"yadro-intern/internal/model"
+)
+
+// TariffRate prices the session started at the given time by the time of the day tariffs,
+// the time out of any tariff costs the base price.
+type TariffRate struct {
+	base    int
+	tariffs []*model.Tariff
+	from    time.Time
+}
+
+func NewTariffRate(base int, tariffs []*model.Tariff, from time.Time) *TariffRate {
+	return &TariffRate{base: base, tariffs: tariffs, from: from}
+}
+
+// Cost splits the time after the session start by the tariff boundaries
+// and sums up the cost of every part.
+func (r *TariffRate) Cost(d time.Duration) float64 {
+	var (
+		cost float64
+		end  = r.from.Add(d)
+	)
+
+	for t := r.from; t.Before(end); {
+		price, next := r.priceAt(t)
+		if next.After(end) {
+			next = end
+		}
+
+		cost += float64(price) * next.Sub(t).Hours()
+		t = next
+	}
+
+	return cost
+}
+
+// priceAt returns the price at the moment and the closest moment, when the price can change.
+func (r *TariffRate) priceAt(t time.Time) (int, time.Time) {
+	var (
+		price = r.base
+		next  = t.AddDate(0, 0, 1)
+		probe = model.NewTariff(t, t.Add(time.Second), 0, "")
+	)
+
+	for _, tariff := range r.tariffs {
+		if tariff.Overlaps(probe) {
+			price = tariff.PricePerHour
+		}
+
+		for _, boundary := range []time.Time{tariff.From, tariff.To} {
+			if b := nextClockTime(t, boundary); b.Before(next) {
+				next = b
+			}
+		}
+	}
+
+	return price, next
+}
+
+// nextClockTime returns the first moment after t, when the clock shows the time of clock.
+//
+// time.Date is used instead of adding durations, so the moment is correct
+// even when the day is shorter or longer because of the daylight saving time.
+func nextClockTime(t, clock time.Time) time.Time {
+	at := func(day int) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day()+day, clock.Hour(), clock.Minute(), clock.Second(), 0, t.Location())
+	}
+
+	if next := at(0); next.After(t) {
+		return next
+	}
+
+	return at(1)
+}
+
+// shiftedRate prices the session, which is started offset later than the session of the rate.
+type shiftedRate struct {
+	rate   Rate
+	offset time.Duration
+}
+
+func (r shiftedRate) Cost(d time.Duration) float64 {
+	return r.rate.Cost(r.offset+d) - r.rate.Cost(r.offset)
+}
@@ -0,0 +1,103 @@
+package follow
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+	"yadro-intern/internal/model"
+)
+
+// Reader reads the underlying reader until the context is done,
+// after that it returns io.EOF, even if the underlying read is still blocked.
+//
+// In the follow mode EOF of the underlying reader isn't final:
+// reading is retried every poll interval, like "tail -f" does for a growing file.
+type Reader struct {
+	ctx    context.Context
+	r      io.Reader
+	follow bool
+	poll   time.Duration
+
+	// results is not nil, while the underlying read is in progress.
+	results chan readResult
+	buf     []byte
+
+	// leftover is the data read, but not returned yet.
+	leftover []byte
+}
+
+type readResult struct {
+	n   int
+	err error
+}
+
+func NewReader(ctx context.Context, r io.Reader, follow bool, poll time.Duration) *Reader {
+	return &Reader{ctx: ctx, r: r, follow: follow, poll: poll}
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	if len(r.leftover) > 0 {
+		n := copy(p, r.leftover)
+		r.leftover = r.leftover[n:]
+		return n, nil
+	}
+
+	for {
+		if r.results == nil {
+			r.startRead(len(p))
+		}
+
+		select {
+		case <-r.ctx.Done():
+			return 0, io.EOF
+		case res := <-r.results:
+			r.results = nil
+
+			if res.n > 0 {
+				n := copy(p, r.buf[:res.n])
+				r.leftover = r.buf[n:res.n]
+				return n, nil
+			}
+
+			switch {
+			case res.err == nil:
+				continue
+			case !errors.Is(res.err, io.EOF):
+				return 0, res.err
+			case !r.follow:
+				return 0, io.EOF
+			}
+
+			select {
+			case <-r.ctx.Done():
+				return 0, io.EOF
+			case <-time.After(r.poll):
+			}
+		}
+	}
+}
+
+// startRead reads the underlying reader in a separate goroutine,
+// so the blocked read doesn't block the context cancellation.
+func (r *Reader) startRead(size int) {
+	buf := make([]byte, size)
+	results := make(chan readResult, 1)
+
+	r.buf, r.results = buf, results
+	go func() {
+		n, err := r.r.Read(buf)
+		results <- readResult{n: n, err: err}
+	}()
+}
+
+// UntilClosing returns the time left until the closest closing of the club.
+func UntilClosing(now time.Time, workingTime *model.TimeInterval) time.Duration {
+	end := workingTime.End
+	closing := time.Date(now.Year(), now.Month(), now.Day(), end.Hour(), end.Minute(), end.Second(), 0, now.Location())
+	if !closing.After(now) {
+		closing = time.Date(now.Year(), now.Month(), now.Day()+1, end.Hour(), end.Minute(), end.Second(), 0, now.Location())
+	}
+
+	return closing.Sub(now)
+}
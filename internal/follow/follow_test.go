@@ -0,0 +1,154 @@
+package follow
+
+import (
+	"context"
+	"github.com/stretchr/testify/suite"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"yadro-intern/internal/model"
+)
+
+const testPoll = 5 * time.Millisecond
+
+type followSuite struct {
+	suite.Suite
+}
+
+func TestFollowSuite(t *testing.T) {
+	suite.Run(t, new(followSuite))
+}
+
+func (s *followSuite) TestReadWithoutFollow() {
+	r := NewReader(context.Background(), strings.NewReader("09:00 1 client1\n"), false, testPoll)
+
+	content, err := io.ReadAll(r)
+	s.Require().NoError(err)
+	s.Require().Equal("09:00 1 client1\n", string(content))
+}
+
+func (s *followSuite) TestReadSmallBuffer() {
+	r := NewReader(context.Background(), strings.NewReader("client1"), false, testPoll)
+
+	var content []byte
+	buf := make([]byte, 3)
+	for {
+		n, err := r.Read(buf)
+		content = append(content, buf[:n]...)
+		if err != nil {
+			s.Require().ErrorIs(err, io.EOF)
+			break
+		}
+	}
+
+	s.Require().Equal("client1", string(content))
+}
+
+func (s *followSuite) TestFollowGrowingFile() {
+	filename := filepath.Join(s.T().TempDir(), "input.txt")
+	s.Require().NoError(os.WriteFile(filename, []byte("first\n"), 0o600))
+
+	f, err := os.Open(filename)
+	s.Require().NoError(err)
+	defer func() {
+		_ = f.Close()
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r := NewReader(ctx, f, true, testPoll)
+	buf := make([]byte, 64)
+
+	n, err := r.Read(buf)
+	s.Require().NoError(err)
+	s.Require().Equal("first\n", string(buf[:n]))
+
+	go func() {
+		time.Sleep(5 * testPoll)
+		w, e := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0o600)
+		if e != nil {
+			return
+		}
+
+		_, _ = w.WriteString("second\n")
+		_ = w.Close()
+	}()
+
+	// EOF of the file isn't final, the reader waits for the appended line
+	n, err = r.Read(buf)
+	s.Require().NoError(err)
+	s.Require().Equal("second\n", string(buf[:n]))
+
+	cancel()
+	_, err = r.Read(buf)
+	s.Require().ErrorIs(err, io.EOF)
+}
+
+func (s *followSuite) TestCancelBlockedRead() {
+	pr, pw := io.Pipe()
+	defer func() {
+		_ = pw.Close()
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := NewReader(ctx, pr, true, testPoll)
+
+	time.AfterFunc(5*testPoll, cancel)
+
+	_, err := r.Read(make([]byte, 8))
+	s.Require().ErrorIs(err, io.EOF)
+}
+
+func (s *followSuite) TestUntilClosing() {
+	workingTime := model.NewTimeInterval(
+		time.Date(0, 1, 1, 9, 0, 0, 0, time.UTC),
+		time.Date(0, 1, 1, 19, 0, 0, 0, time.UTC),
+	)
+
+	overnight := model.NewTimeInterval(
+		time.Date(0, 1, 1, 20, 0, 0, 0, time.UTC),
+		time.Date(0, 1, 1, 4, 0, 0, 0, time.UTC),
+	)
+
+	testCases := []struct {
+		name        string
+		now         time.Time
+		workingTime *model.TimeInterval
+		exp         time.Duration
+	}{
+		{
+			name:        "before closing",
+			now:         time.Date(2023, 3, 1, 18, 30, 0, 0, time.UTC),
+			workingTime: workingTime,
+			exp:         30 * time.Minute,
+		},
+		{
+			name:        "before opening",
+			now:         time.Date(2023, 3, 1, 8, 0, 0, 0, time.UTC),
+			workingTime: workingTime,
+			exp:         11 * time.Hour,
+		},
+		{
+			name:        "after closing, closes the next day",
+			now:         time.Date(2023, 3, 1, 19, 0, 0, 0, time.UTC),
+			workingTime: workingTime,
+			exp:         24 * time.Hour,
+		},
+		{
+			name:        "overnight",
+			now:         time.Date(2023, 3, 1, 22, 0, 0, 0, time.UTC),
+			workingTime: overnight,
+			exp:         6 * time.Hour,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.Require().Equal(tc.exp, UntilClosing(tc.now, tc.workingTime))
+		})
+	}
+}
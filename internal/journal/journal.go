@@ -0,0 +1,71 @@
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"yadro-intern/internal/logfile"
+)
+
+// Journal is the append-only file of the records, one JSON object per line.
+type Journal struct {
+	f   *os.File
+	seq int
+}
+
+// OpenJournal reads all records of the journal and opens it for appending,
+// the missing journal is created.
+//
+// The last line without the line break is the record torn by the crash,
+// it's cut off, so the next records are appended after the valid ones.
+func OpenJournal(path string) (*Journal, []*Record, error) {
+	var records []*Record
+	f, err := logfile.Open(path, func(line []byte) error {
+		var record Record
+		if err := json.Unmarshal(line, &record); err != nil {
+			return fmt.Errorf("invalid journal record %d: %s", len(records)+1, err)
+		}
+
+		if record.Seq != len(records)+1 {
+			return fmt.Errorf("journal record %d has number %d", len(records)+1, record.Seq)
+		}
+
+		records = append(records, &record)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open journal: %s", err)
+	}
+
+	return &Journal{f: f, seq: len(records)}, records, nil
+}
+
+// Append writes the record with the next number, it's durable only after Sync.
+func (j *Journal) Append(r *Record) error {
+	r.Seq = j.seq + 1
+
+	line, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to encode record: %s", err)
+	}
+
+	if _, err = j.f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write record: %s", err)
+	}
+
+	j.seq = r.Seq
+	return nil
+}
+
+// Seq returns the number of the last record.
+func (j *Journal) Seq() int {
+	return j.seq
+}
+
+func (j *Journal) Sync() error {
+	return j.f.Sync()
+}
+
+func (j *Journal) Close() error {
+	return j.f.Close()
+}
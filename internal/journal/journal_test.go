@@ -0,0 +1,317 @@
+package journal
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/suite"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"yadro-intern/cmd/config"
+	"yadro-intern/internal/billing"
+	"yadro-intern/internal/model"
+	"yadro-intern/internal/processor"
+	"yadro-intern/internal/storage"
+)
+
+const tablesCount = 3
+
+type journalSuite struct {
+	suite.Suite
+
+	cfg      *config.Processor
+	coreData *model.CoreData
+}
+
+func TestJournalSuite(t *testing.T) {
+	suite.Run(t, new(journalSuite))
+}
+
+func (s *journalSuite) SetupSuite() {
+	cfg, err := config.NewProcessorConfig()
+	s.Require().NoError(err)
+
+	s.cfg = cfg
+	s.coreData = model.NewCoreData(tablesCount, 10, model.NewTimeInterval(at(9, 0), at(19, 0)))
+}
+
+func at(hour, minute int) time.Time {
+	return time.Date(0, 1, 1, hour, minute, 0, 0, time.UTC)
+}
+
+func (s *journalSuite) newProcessor(out *bytes.Buffer) *processor.EventProcessorImpl {
+	return processor.NewEventProcessor(
+		out,
+		s.cfg,
+		s.coreData,
+		billing.NewHourly(),
+		storage.NewInMemoryStorage[int, *model.IncomingEvent](),
+		storage.NewInMemoryStorage[int, *model.RevenueStats](),
+		storage.NewInMemoryStorage[string, int](),
+		storage.NewInMemoryQueue[model.ClientData](nil),
+	)
+}
+
+// exampleEvents are the events of the task example.
+func exampleEvents() []*model.IncomingEvent {
+	return []*model.IncomingEvent{
+		model.NewIncomingEvent(at(8, 48), model.Arrives, model.NewClientArrives("client1")),
+		model.NewIncomingEvent(at(9, 41), model.Arrives, model.NewClientArrives("client1")),
+		model.NewIncomingEvent(at(9, 48), model.Arrives, model.NewClientArrives("client2")),
+		model.NewIncomingEvent(at(9, 52), model.Waits, model.NewClientWaits("client1")),
+		model.NewIncomingEvent(at(9, 54), model.Sits, model.NewClientSits("client1", 1, tablesCount)),
+		model.NewIncomingEvent(at(10, 25), model.Sits, model.NewClientSits("client2", 2, tablesCount)),
+		model.NewIncomingEvent(at(10, 58), model.Arrives, model.NewClientArrives("client3")),
+		model.NewIncomingEvent(at(10, 59), model.Sits, model.NewClientSits("client3", 3, tablesCount)),
+		model.NewIncomingEvent(at(11, 30), model.Arrives, model.NewClientArrives("client4")),
+		model.NewIncomingEvent(at(11, 35), model.Sits, model.NewClientSits("client4", 2, tablesCount)),
+		model.NewIncomingEvent(at(11, 45), model.Waits, model.NewClientWaits("client4")),
+		model.NewIncomingEvent(at(12, 33), model.Leaves, model.NewClientLeaves("client1")),
+		model.NewIncomingEvent(at(12, 43), model.Leaves, model.NewClientLeaves("client2")),
+		model.NewIncomingEvent(at(15, 52), model.Leaves, model.NewClientLeaves("client4")),
+	}
+}
+
+// record applies the events to the new processor, which is recorded to the directory.
+func (s *journalSuite) record(dir string, events []*model.IncomingEvent, snapshotInterval int, closeDay bool) *processor.EventProcessorImpl {
+	p := s.newProcessor(&bytes.Buffer{})
+
+	recorder, restored, err := Open(dir, p, tablesCount, snapshotInterval)
+	s.Require().NoError(err)
+	s.Require().Equal(&Restored{}, restored)
+
+	for _, event := range events {
+		s.Require().NoError(recorder.RecordIncoming(event))
+		p.Apply(event)
+		s.Require().NoError(recorder.Checkpoint())
+	}
+
+	if closeDay {
+		s.Require().NoError(recorder.MarkClosed())
+		p.Finish()
+		s.Require().NoError(recorder.Checkpoint())
+	}
+
+	s.Require().NoError(recorder.Close())
+	return p
+}
+
+func (s *journalSuite) TestRestore() {
+	events := exampleEvents()
+
+	testCases := []struct {
+		name             string
+		events           []*model.IncomingEvent
+		snapshotInterval int
+		closeDay         bool
+		expReplayed      string
+	}{
+		{
+			name:        "journal only",
+			events:      events[:5],
+			expReplayed: "08:48 1 client1\n08:48 13 NotOpenYet\n09:41 1 client1\n09:48 1 client2\n09:52 3 client1\n09:52 13 ICanWaitNoLonger!\n09:54 2 client1 1\n",
+		},
+		{
+			name:             "snapshot and journal tail",
+			events:           events,
+			snapshotInterval: 4,
+			expReplayed:      "12:43 4 client2\n15:52 4 client4\n",
+		},
+		{
+			name:             "snapshot is the last record",
+			events:           events[:12],
+			snapshotInterval: 4,
+		},
+		{
+			name:        "closed day",
+			events:      events,
+			closeDay:    true,
+			expReplayed: "19:00 11 client3\n19:00\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			dir := s.T().TempDir()
+			recorded := s.record(dir, tc.events, tc.snapshotInterval, tc.closeDay)
+
+			out := &bytes.Buffer{}
+			p := s.newProcessor(out)
+			recorder, restored, err := Open(dir, p, tablesCount, tc.snapshotInterval)
+			s.Require().NoError(err)
+			defer func() {
+				_ = recorder.Close()
+			}()
+
+			s.Require().Equal(recorded.State(), p.State())
+			s.Require().Equal(tc.closeDay, restored.Closed)
+			s.Require().Equal(tc.events[len(tc.events)-1], restored.LastEvent)
+
+			// journal is replayed deterministically, the tail after the snapshot only
+			s.Require().True(bytes.HasSuffix(out.Bytes(), []byte(tc.expReplayed)), out.String())
+			if tc.snapshotInterval > 0 {
+				s.Require().Equal(tc.expReplayed, out.String())
+			}
+		})
+	}
+}
+
+func (s *journalSuite) TestRestoreAndContinue() {
+	events := exampleEvents()
+	dir := s.T().TempDir()
+	s.record(dir, events[:7], 3, false)
+
+	p := s.newProcessor(&bytes.Buffer{})
+	recorder, restored, err := Open(dir, p, tablesCount, 3)
+	s.Require().NoError(err)
+	s.Require().Equal(9, restored.Records)
+
+	for _, event := range events[7:] {
+		s.Require().NoError(recorder.RecordIncoming(event))
+		p.Apply(event)
+		s.Require().NoError(recorder.Checkpoint())
+	}
+
+	s.Require().NoError(recorder.Close())
+
+	// the journal of two runs is the same as the journal of the single run
+	singleRun := s.T().TempDir()
+	s.record(singleRun, events, 0, false)
+
+	twoRuns, err := os.ReadFile(filepath.Join(dir, journalFile))
+	s.Require().NoError(err)
+
+	expected, err := os.ReadFile(filepath.Join(singleRun, journalFile))
+	s.Require().NoError(err)
+	s.Require().Equal(string(expected), string(twoRuns))
+}
+
+func (s *journalSuite) TestRestoreToKeptState() {
+	events := exampleEvents()
+	dir := s.T().TempDir()
+	recorded := s.record(dir, events, 0, false)
+
+	// the processor keeps the state of the previous run, the journal isn't applied on top of it
+	p := s.newProcessor(&bytes.Buffer{})
+	for _, event := range events {
+		p.Apply(event)
+	}
+
+	recorder, _, err := Open(dir, p, tablesCount, 0)
+	s.Require().NoError(err)
+	s.Require().NoError(recorder.Close())
+
+	s.Require().Equal(recorded.State(), p.State())
+}
+
+func (s *journalSuite) TestRestoreGeneratedBeforeIncoming() {
+	slot := model.NewTimeInterval(at(10, 0), at(11, 0))
+	events := []*model.IncomingEvent{
+		model.NewIncomingEvent(at(9, 10), model.Arrives, model.NewClientArrives("client2")),
+		model.NewIncomingEvent(at(9, 20), model.Sits, model.NewClientSits("client2", 1, tablesCount)),
+		model.NewIncomingEvent(at(9, 21), model.Arrives, model.NewClientArrives("client3")),
+		model.NewIncomingEvent(at(9, 22), model.Sits, model.NewClientSits("client3", 2, tablesCount)),
+		model.NewIncomingEvent(at(9, 23), model.Arrives, model.NewClientArrives("client4")),
+		model.NewIncomingEvent(at(9, 24), model.Sits, model.NewClientSits("client4", 3, tablesCount)),
+		model.NewIncomingEvent(at(9, 25), model.Reserves, model.NewClientReserves("client1", 1, tablesCount, slot)),
+		model.NewIncomingEvent(at(9, 30), model.Arrives, model.NewClientArrives("client1")),
+		model.NewIncomingEvent(at(9, 31), model.Waits, model.NewClientWaits("client1")),
+
+		// client2 gives the table to the waiting client1 at 10:00, before the event is written
+		model.NewIncomingEvent(at(10, 30), model.Arrives, model.NewClientArrives("client5")),
+	}
+
+	dir := s.T().TempDir()
+	recorded := s.record(dir, events, 0, false)
+
+	out := &bytes.Buffer{}
+	p := s.newProcessor(out)
+	recorder, _, err := Open(dir, p, tablesCount, 0)
+	s.Require().NoError(err)
+	s.Require().NoError(recorder.Close())
+
+	s.Require().Equal(recorded.State(), p.State())
+	s.Require().True(bytes.HasSuffix(out.Bytes(), []byte("10:00 11 client2\n10:00 12 client1 1\n10:30 1 client5\n")), out.String())
+}
+
+func (s *journalSuite) TestRestoreTornRecord() {
+	events := exampleEvents()
+	dir := s.T().TempDir()
+	recorded := s.record(dir, events[:5], 0, false)
+
+	journalPath := filepath.Join(dir, journalFile)
+	f, err := os.OpenFile(journalPath, os.O_APPEND|os.O_WRONLY, 0o600)
+	s.Require().NoError(err)
+	_, err = f.WriteString(`{"seq":8,"kind":"incom`)
+	s.Require().NoError(err)
+	s.Require().NoError(f.Close())
+
+	p := s.newProcessor(&bytes.Buffer{})
+	recorder, _, err := Open(dir, p, tablesCount, 0)
+	s.Require().NoError(err)
+	s.Require().Equal(recorded.State(), p.State())
+
+	s.Require().NoError(recorder.RecordIncoming(events[5]))
+	p.Apply(events[5])
+	s.Require().NoError(recorder.Checkpoint())
+	s.Require().NoError(recorder.Close())
+
+	_, records, err := OpenJournal(journalPath)
+	s.Require().NoError(err)
+	s.Require().Len(records, 8)
+	s.Require().Equal(KindIncoming, records[7].Kind)
+	s.Require().Equal("client2", records[7].Client)
+}
+
+func (s *journalSuite) TestRestoreDiverged() {
+	dir := s.T().TempDir()
+	s.record(dir, exampleEvents()[:2], 0, false)
+
+	// the journal says, that the club was open at 08:48
+	journalPath := filepath.Join(dir, journalFile)
+	content, err := os.ReadFile(journalPath)
+	s.Require().NoError(err)
+	content = bytes.Replace(content, []byte(`"error":"NotOpenYet"`), []byte(`"error":"YouShallNotPass"`), 1)
+	s.Require().NoError(os.WriteFile(journalPath, content, 0o600))
+
+	_, _, err = Open(dir, s.newProcessor(&bytes.Buffer{}), tablesCount, 0)
+	s.Require().ErrorIs(err, ErrDiverged)
+}
+
+func (s *journalSuite) TestSnapshotNewerThanJournal() {
+	dir := s.T().TempDir()
+	s.record(dir, exampleEvents()[:2], 1, false)
+	s.Require().NoError(os.Remove(filepath.Join(dir, journalFile)))
+
+	_, _, err := Open(dir, s.newProcessor(&bytes.Buffer{}), tablesCount, 0)
+	s.Require().Error(err)
+}
+
+func (s *journalSuite) TestSnapshotRoundTrip() {
+	state := &processor.State{
+		Tables:  []processor.TableState{{Table: 1, Client: "client1", Since: at(10, 0)}},
+		Queue:   []string{"client3"},
+		Clients: []processor.ClientState{{Name: "client1", Table: 1}, {Name: "client3"}},
+		Revenue: []processor.TableRevenue{{Table: 1, Stats: model.RevenueStats{
+			Income:        20,
+			UsageTime:     90 * time.Minute,
+			Sessions:      2,
+			FirstOccupied: at(8, 0),
+			LastReleased:  at(9, 30),
+		}}},
+		Day:      1,
+		Sessions: []model.Session{*model.NewSession("client2", 1, at(8, 0), at(9, 30), 2*time.Hour, 20)},
+	}
+
+	path := filepath.Join(s.T().TempDir(), snapshotFile)
+	s.Require().NoError(WriteSnapshot(path, newSnapshot(7, state)))
+
+	snapshot, err := ReadSnapshot(path)
+	s.Require().NoError(err)
+	s.Require().Equal(7, snapshot.Seq)
+	s.Require().Equal(state, snapshot.State())
+
+	snapshot, err = ReadSnapshot(filepath.Join(s.T().TempDir(), snapshotFile))
+	s.Require().NoError(err)
+	s.Require().Nil(snapshot)
+}
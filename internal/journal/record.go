@@ -0,0 +1,127 @@
+package journal
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"yadro-intern/internal/ifces"
+	"yadro-intern/internal/model"
+)
+
+// kinds of the journal records
+const (
+	KindIncoming = "incoming"
+	KindOutgoing = "outgoing"
+
+	// KindClosed marks the end of the working day,
+	// events after it can't be applied.
+	KindClosed = "closed"
+)
+
+// Record is the line of the journal.
+type Record struct {
+
+	// Seq is the number of the record in the journal, starting from 1.
+	Seq int `json:"seq"`
+
+	Kind   string    `json:"kind"`
+	Time   time.Time `json:"time"`
+	Type   int       `json:"type,omitempty"`
+	Client string    `json:"client,omitempty"`
+	Table  int       `json:"table,omitempty"`
+	Error  string    `json:"error,omitempty"`
+
+	// From and To are the reserved time of the reservation.
+	From *time.Time `json:"from,omitempty"`
+	To   *time.Time `json:"to,omitempty"`
+}
+
+func newRecord(event ifces.TimeFormatter) (*Record, error) {
+	switch e := event.(type) {
+	case *model.IncomingEvent:
+		r := &Record{
+			Kind:   KindIncoming,
+			Time:   e.HappensAt,
+			Type:   int(e.Type),
+			Client: e.Client.GetName(),
+			Table:  tableOf(e.Client),
+		}
+
+		if reserves, ok := e.Client.(*model.ClientReserves); ok {
+			r.From, r.To = &reserves.GetSlot().Start, &reserves.GetSlot().End
+		}
+
+		return r, nil
+	case *model.OutgoingEvent:
+		r := &Record{
+			Kind: KindOutgoing,
+			Time: e.HappensAt,
+			Type: int(e.Type),
+		}
+
+		if e.Err != nil {
+			r.Error = e.Err.Error()
+		} else {
+			r.Client = e.Client.GetName()
+			r.Table = tableOf(e.Client)
+		}
+
+		return r, nil
+	}
+
+	return nil, fmt.Errorf("unknown event: %T", event)
+}
+
+// IncomingEvent returns the incoming event of the record.
+func (r *Record) IncomingEvent(maxTables int) (*model.IncomingEvent, error) {
+	if r.Kind != KindIncoming {
+		return nil, fmt.Errorf("record %d isn't incoming", r.Seq)
+	}
+
+	var slot *model.TimeInterval
+	if r.From != nil && r.To != nil {
+		slot = &model.TimeInterval{Start: *r.From, End: *r.To}
+	}
+
+	eventType := model.IncomingEventType(r.Type)
+	client, ok := model.NewClientData(eventType, r.Client, r.Table, maxTables, slot)
+	if !ok {
+		return nil, fmt.Errorf("record %d has unknown event type %d", r.Seq, r.Type)
+	}
+
+	return model.NewIncomingEvent(r.Time, eventType, client), nil
+}
+
+// sameEvent checks, that the records describe the same event regardless of their numbers.
+func (r *Record) sameEvent(other *Record) bool {
+	return r.Kind == other.Kind &&
+		r.Time.Equal(other.Time) &&
+		r.Type == other.Type &&
+		r.Client == other.Client &&
+		r.Table == other.Table &&
+		r.Error == other.Error &&
+		sameTime(r.From, other.From) &&
+		sameTime(r.To, other.To)
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Equal(*b)
+}
+
+// ErrDiverged means, that replaying the journal generates other events, than recorded.
+var ErrDiverged = errors.New("replay diverged from the journal")
+
+func tableOf(client model.ClientData) int {
+	switch c := client.(type) {
+	case *model.ClientSits:
+		return c.GetTable()
+	case *model.ClientReserves:
+		return c.GetTable()
+	}
+
+	return 0
+}
@@ -0,0 +1,284 @@
+package journal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"yadro-intern/internal/ifces"
+	"yadro-intern/internal/model"
+	"yadro-intern/internal/processor"
+)
+
+// files of the recorder directory
+const (
+	journalFile  = "journal.jsonl"
+	snapshotFile = "snapshot.json"
+)
+
+// Recorder keeps every event written by the processor in the journal
+// and takes snapshots of its state, so the processor can be restored after a crash.
+type Recorder struct {
+	dir       string
+	processor *processor.EventProcessorImpl
+	journal   *Journal
+
+	// tail is the journal records after the snapshot,
+	// written events are compared with them while replaying.
+	tail []*Record
+	next int
+
+	// incoming is the incoming event recorded before it's applied,
+	// it's skipped, when the processor writes it.
+	incoming *Record
+
+	// snapshotInterval is the count of incoming events between snapshots, 0 disables them.
+	snapshotInterval int
+	sinceSnapshot    int
+
+	// err is the first recording error, events aren't recorded after it.
+	err error
+}
+
+// Restored describes the state the processor is restored to.
+type Restored struct {
+
+	// Records is the count of the journal records.
+	Records int
+
+	// LastEvent is the latest applied incoming event, nil if there are no events.
+	LastEvent *model.IncomingEvent
+
+	// Closed is set, when the working day is already closed.
+	Closed bool
+}
+
+// Open restores the processor from the latest snapshot and the journal tail
+// kept in the directory, then records the events written by the processor.
+// Without the snapshot the whole journal is replayed from the empty state, whatever the processor keeps.
+//
+// Replayed events are written by the processor again, but aren't recorded twice:
+// they are compared with the journal, the replay generating other events is an error.
+//
+// Incoming events must be recorded by RecordIncoming before they are applied to the processor.
+// The events generated because of the incoming one follow it in the journal,
+// even those written before it, when the time passes, for example, expired reservations.
+func Open(dir string, p *processor.EventProcessorImpl, maxTables, snapshotInterval int) (*Recorder, *Restored, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, nil, fmt.Errorf("could not create journal directory: %s", err)
+	}
+
+	snapshot, err := ReadSnapshot(filepath.Join(dir, snapshotFile))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	j, records, err := OpenJournal(filepath.Join(dir, journalFile))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r := &Recorder{
+		dir:              dir,
+		processor:        p,
+		journal:          j,
+		snapshotInterval: snapshotInterval,
+	}
+
+	restored, err := r.restore(snapshot, records, maxTables)
+	if err != nil {
+		_ = j.Close()
+		return nil, nil, err
+	}
+
+	return r, restored, nil
+}
+
+func (r *Recorder) restore(snapshot *Snapshot, records []*Record, maxTables int) (*Restored, error) {
+	restored := &Restored{Records: len(records)}
+
+	from := 0
+	if snapshot != nil {
+		if snapshot.Seq > len(records) {
+			return nil, fmt.Errorf("snapshot of record %d is newer than the journal of %d records", snapshot.Seq, len(records))
+		}
+
+		r.processor.Restore(snapshot.State())
+		from = snapshot.Seq
+	} else {
+		// storages of the processor may be persistent, the journal is replayed from the empty state
+		r.processor.Restore(&processor.State{})
+	}
+
+	// records before the snapshot are already applied to its state
+	for _, record := range records[:from] {
+		if err := restored.observe(record, maxTables); err != nil {
+			return nil, err
+		}
+	}
+
+	r.tail = records[from:]
+	r.processor.AddListener(r.record)
+
+	for r.next < len(r.tail) && r.err == nil {
+		record := r.tail[r.next]
+		if err := restored.observe(record, maxTables); err != nil {
+			return nil, err
+		}
+
+		switch record.Kind {
+		case KindIncoming:
+			r.next++
+			r.incoming = record
+			r.processor.Apply(restored.LastEvent)
+		case KindClosed:
+			r.next++
+			r.processor.Finish()
+		default:
+			// outgoing events are written only by the incoming ones, which are already replayed
+			r.err = fmt.Errorf("%w: record %d", ErrDiverged, record.Seq)
+		}
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	// events generated by the replay, but missing in the journal, are recorded now
+	if err := r.journal.Sync(); err != nil {
+		return nil, fmt.Errorf("failed to sync journal: %s", err)
+	}
+
+	return restored, nil
+}
+
+func (s *Restored) observe(record *Record, maxTables int) error {
+	switch record.Kind {
+	case KindIncoming:
+		event, err := record.IncomingEvent(maxTables)
+		if err != nil {
+			return err
+		}
+
+		s.LastEvent = event
+	case KindClosed:
+		s.Closed = true
+	}
+
+	return nil
+}
+
+// record is the processor listener.
+func (r *Recorder) record(event ifces.TimeFormatter) {
+	if r.err != nil {
+		return
+	}
+
+	record, err := newRecord(event)
+	if err != nil {
+		r.err = err
+		return
+	}
+
+	if r.incoming != nil && r.incoming.sameEvent(record) {
+		r.incoming = nil
+		return
+	}
+
+	if r.next < len(r.tail) {
+		expected := r.tail[r.next]
+		r.next++
+
+		if !expected.sameEvent(record) {
+			r.err = fmt.Errorf("%w: record %d", ErrDiverged, expected.Seq)
+		}
+
+		return
+	}
+
+	r.err = r.journal.Append(record)
+}
+
+// RecordIncoming makes the incoming event durable, it's called before the event is applied,
+// so the event isn't applied, if it can't be recorded.
+func (r *Recorder) RecordIncoming(event *model.IncomingEvent) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	record, err := newRecord(event)
+	if err != nil {
+		return err
+	}
+
+	if r.err = r.journal.Append(record); r.err != nil {
+		return r.err
+	}
+
+	if err = r.journal.Sync(); err != nil {
+		r.err = fmt.Errorf("failed to sync journal: %s", err)
+		return r.err
+	}
+
+	r.incoming = record
+	return nil
+}
+
+// Checkpoint makes the recorded events durable, it's called after the incoming event is applied.
+//
+// The incoming event is durable already, the events generated by it are written again by the replay,
+// so the error means only, that the next events can't be recorded.
+//
+// Every snapshot interval of incoming events the snapshot of the processor is taken.
+func (r *Recorder) Checkpoint() error {
+	if r.err != nil {
+		return r.err
+	}
+
+	if err := r.journal.Sync(); err != nil {
+		r.err = fmt.Errorf("failed to sync journal: %s", err)
+		return r.err
+	}
+
+	r.sinceSnapshot++
+	if r.snapshotInterval == 0 || r.sinceSnapshot < r.snapshotInterval {
+		return nil
+	}
+
+	return r.Snapshot()
+}
+
+// Snapshot writes the snapshot of the processor state after the last recorded event.
+func (r *Recorder) Snapshot() error {
+	if r.err != nil {
+		return r.err
+	}
+
+	snapshot := newSnapshot(r.journal.Seq(), r.processor.State())
+	if err := WriteSnapshot(filepath.Join(r.dir, snapshotFile), snapshot); err != nil {
+		return err
+	}
+
+	r.sinceSnapshot = 0
+	return nil
+}
+
+// MarkClosed makes the end of the working day durable, it's called before the processor finishes it.
+func (r *Recorder) MarkClosed() error {
+	if r.err != nil {
+		return r.err
+	}
+
+	if r.err = r.journal.Append(&Record{Kind: KindClosed}); r.err != nil {
+		return r.err
+	}
+
+	if err := r.journal.Sync(); err != nil {
+		r.err = fmt.Errorf("failed to sync journal: %s", err)
+	}
+
+	return r.err
+}
+
+func (r *Recorder) Close() error {
+	return r.journal.Close()
+}
@@ -0,0 +1,192 @@
+package journal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+	"yadro-intern/internal/logfile"
+	"yadro-intern/internal/model"
+	"yadro-intern/internal/processor"
+)
+
+// Snapshot is the state of the processor after the journal record.
+type Snapshot struct {
+
+	// Seq is the number of the last journal record applied to the state.
+	Seq int `json:"seq"`
+
+	Tables  []snapshotTable   `json:"tables"`
+	Queue   []string          `json:"queue"`
+	Clients []snapshotClient  `json:"clients"`
+	Revenue []snapshotRevenue `json:"revenue"`
+
+	Reservations []snapshotReservation `json:"reservations,omitempty"`
+	Balances     []snapshotBalance     `json:"balances,omitempty"`
+
+	Day      int               `json:"day,omitempty"`
+	Sessions []snapshotSession `json:"sessions,omitempty"`
+}
+
+type snapshotTable struct {
+	Table  int       `json:"table"`
+	Client string    `json:"client"`
+	Since  time.Time `json:"since"`
+}
+
+type snapshotClient struct {
+	Name  string `json:"name"`
+	Table int    `json:"table,omitempty"`
+}
+
+type snapshotReservation struct {
+	Client string    `json:"client"`
+	Table  int       `json:"table"`
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+}
+
+type snapshotBalance struct {
+	Client  string `json:"client"`
+	Balance int    `json:"balance"`
+}
+
+type snapshotSession struct {
+	Client string        `json:"client"`
+	Table  int           `json:"table"`
+	From   time.Time     `json:"from"`
+	To     time.Time     `json:"to"`
+	Billed time.Duration `json:"billed"`
+	Amount int           `json:"amount"`
+}
+
+type snapshotRevenue struct {
+	Table         int           `json:"table"`
+	Income        int           `json:"income"`
+	UsageTime     time.Duration `json:"usage_time"`
+	Sessions      int           `json:"sessions"`
+	FirstOccupied time.Time     `json:"first_occupied"`
+	LastReleased  time.Time     `json:"last_released"`
+}
+
+func newSnapshot(seq int, state *processor.State) *Snapshot {
+	s := &Snapshot{
+		Seq:     seq,
+		Tables:  make([]snapshotTable, 0, len(state.Tables)),
+		Queue:   state.Queue,
+		Clients: make([]snapshotClient, 0, len(state.Clients)),
+		Revenue: make([]snapshotRevenue, 0, len(state.Revenue)),
+	}
+
+	for _, table := range state.Tables {
+		s.Tables = append(s.Tables, snapshotTable{Table: table.Table, Client: table.Client, Since: table.Since})
+	}
+
+	for _, client := range state.Clients {
+		s.Clients = append(s.Clients, snapshotClient{Name: client.Name, Table: client.Table})
+	}
+
+	for _, revenue := range state.Revenue {
+		s.Revenue = append(s.Revenue, snapshotRevenue{
+			Table:         revenue.Table,
+			Income:        revenue.Stats.Income,
+			UsageTime:     revenue.Stats.UsageTime,
+			Sessions:      revenue.Stats.Sessions,
+			FirstOccupied: revenue.Stats.FirstOccupied,
+			LastReleased:  revenue.Stats.LastReleased,
+		})
+	}
+
+	for _, r := range state.Reservations {
+		s.Reservations = append(s.Reservations, snapshotReservation(r))
+	}
+
+	for _, b := range state.Balances {
+		s.Balances = append(s.Balances, snapshotBalance(b))
+	}
+
+	s.Day = state.Day
+	for _, session := range state.Sessions {
+		s.Sessions = append(s.Sessions, snapshotSession(session))
+	}
+
+	return s
+}
+
+// State returns the state of the processor kept by the snapshot.
+func (s *Snapshot) State() *processor.State {
+	state := &processor.State{
+		Tables:  make([]processor.TableState, 0, len(s.Tables)),
+		Queue:   append(make([]string, 0, len(s.Queue)), s.Queue...),
+		Clients: make([]processor.ClientState, 0, len(s.Clients)),
+		Revenue: make([]processor.TableRevenue, 0, len(s.Revenue)),
+	}
+
+	for _, table := range s.Tables {
+		state.Tables = append(state.Tables, processor.TableState{Table: table.Table, Client: table.Client, Since: table.Since})
+	}
+
+	for _, client := range s.Clients {
+		state.Clients = append(state.Clients, processor.ClientState{Name: client.Name, Table: client.Table})
+	}
+
+	for _, revenue := range s.Revenue {
+		state.Revenue = append(state.Revenue, processor.TableRevenue{
+			Table: revenue.Table,
+			Stats: model.RevenueStats{
+				Income:        revenue.Income,
+				UsageTime:     revenue.UsageTime,
+				Sessions:      revenue.Sessions,
+				FirstOccupied: revenue.FirstOccupied,
+				LastReleased:  revenue.LastReleased,
+			},
+		})
+	}
+
+	for _, r := range s.Reservations {
+		state.Reservations = append(state.Reservations, processor.ReservationState(r))
+	}
+
+	for _, b := range s.Balances {
+		state.Balances = append(state.Balances, processor.BalanceState(b))
+	}
+
+	state.Day = s.Day
+	for _, session := range s.Sessions {
+		state.Sessions = append(state.Sessions, model.Session(session))
+	}
+
+	return state
+}
+
+// WriteSnapshot replaces the snapshot file atomically,
+// so the crash while writing keeps the previous snapshot.
+func WriteSnapshot(path string, s *Snapshot) error {
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %s", err)
+	}
+
+	return logfile.WriteAtomically(path, content)
+}
+
+// ReadSnapshot reads the snapshot file, nil is returned, if there is no snapshot yet.
+func ReadSnapshot(path string) (*Snapshot, error) {
+	content, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read snapshot: %s", err)
+	}
+
+	var s Snapshot
+	if err = json.Unmarshal(content, &s); err != nil {
+		return nil, fmt.Errorf("invalid snapshot: %s", err)
+	}
+
+	return &s, nil
+}
@@ -0,0 +1,94 @@
+package logfile
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Open reads the lines of the append-only log and opens it for appending, the missing log is created.
+// Every whole line is passed to read, the error of read stops the opening.
+//
+// The last line without the line break is the line torn by the crash,
+// it's cut off, so the next lines are appended after the valid ones.
+func Open(path string, read func(line []byte) error) (*os.File, error) {
+	f, err := os.OpenFile(filepath.Clean(path), os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("could not open %s: %s", filepath.Base(path), err)
+	}
+
+	size, err := readLines(f, read)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	if err = f.Truncate(size); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to cut off the torn line: %s", err)
+	}
+
+	if _, err = f.Seek(size, io.SeekStart); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to open %s for appending: %s", filepath.Base(path), err)
+	}
+
+	return f, nil
+}
+
+// readLines passes the whole lines to read and returns the size of the log part with them.
+func readLines(r io.Reader, read func(line []byte) error) (int64, error) {
+	var size int64
+
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			return size, nil
+		}
+
+		if err != nil {
+			return 0, fmt.Errorf("failed to read log: %s", err)
+		}
+
+		if err = read(bytes.TrimSpace(line)); err != nil {
+			return 0, err
+		}
+
+		size += int64(len(line))
+	}
+}
+
+// WriteAtomically replaces the file, so the crash while writing keeps the previous content.
+func WriteAtomically(path string, content []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("could not create %s: %s", filepath.Base(path), err)
+	}
+
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err = tmp.Write(content); err == nil {
+		err = tmp.Sync()
+	}
+
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %s", filepath.Base(path), err)
+	}
+
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace %s: %s", filepath.Base(path), err)
+	}
+
+	return nil
+}
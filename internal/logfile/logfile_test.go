@@ -0,0 +1,72 @@
+package logfile
+
+import (
+	"github.com/stretchr/testify/suite"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type logfileSuite struct {
+	suite.Suite
+
+	path string
+}
+
+func TestLogfile(t *testing.T) {
+	suite.Run(t, new(logfileSuite))
+}
+
+func (s *logfileSuite) SetupTest() {
+	s.path = filepath.Join(s.T().TempDir(), "log.jsonl")
+}
+
+func (s *logfileSuite) open() (*os.File, []string) {
+	var lines []string
+	f, err := Open(s.path, func(line []byte) error {
+		lines = append(lines, string(line))
+		return nil
+	})
+	s.Require().NoError(err)
+
+	return f, lines
+}
+
+func (s *logfileSuite) TestOpenCutsTornLine() {
+	s.Require().NoError(os.WriteFile(s.path, []byte("1\n2\n3"), 0o600))
+
+	f, lines := s.open()
+	s.Require().Equal([]string{"1", "2"}, lines)
+
+	// the next line is appended after the whole ones
+	_, err := f.WriteString("4\n")
+	s.Require().NoError(err)
+	s.Require().NoError(f.Close())
+
+	f, lines = s.open()
+	s.Require().NoError(f.Close())
+	s.Require().Equal([]string{"1", "2", "4"}, lines)
+}
+
+func (s *logfileSuite) TestOpenReadError() {
+	s.Require().NoError(os.WriteFile(s.path, []byte("1\n"), 0o600))
+
+	_, err := Open(s.path, func(line []byte) error {
+		return os.ErrInvalid
+	})
+	s.Require().ErrorIs(err, os.ErrInvalid)
+}
+
+func (s *logfileSuite) TestWriteAtomically() {
+	s.Require().NoError(WriteAtomically(s.path, []byte("first")))
+	s.Require().NoError(WriteAtomically(s.path, []byte("second")))
+
+	content, err := os.ReadFile(s.path)
+	s.Require().NoError(err)
+	s.Require().Equal("second", string(content))
+
+	// temporary files are removed
+	entries, err := os.ReadDir(filepath.Dir(s.path))
+	s.Require().NoError(err)
+	s.Require().Len(entries, 1)
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"errors"
 	"fmt"
 	"yadro-intern/internal/apierror"
 )
@@ -12,6 +13,29 @@ type ClientData interface {
 	fmt.Stringer
 }
 
+// NewClientData returns the client data of the incoming event type,
+// table is used only by the events with a table, slot is used only by the reservations.
+//
+// ok is false for an unknown event type.
+func NewClientData(
+	eventType IncomingEventType, name string, table, maxTables int, slot *TimeInterval,
+) (data ClientData, ok bool) {
+	switch eventType {
+	case Reserves:
+		return NewClientReserves(name, table, maxTables, slot), true
+	case Arrives:
+		return NewClientArrives(name), true
+	case Sits:
+		return NewClientSits(name, table, maxTables), true
+	case Waits:
+		return NewClientWaits(name), true
+	case Leaves:
+		return NewClientLeaves(name), true
+	}
+
+	return nil, false
+}
+
 type ClientArrives struct {
 	name string
 }
@@ -109,3 +133,60 @@ func (c *ClientLeaves) String() string {
 func (c *ClientLeaves) Validate() error {
 	return apierror.ValidateName(c.name)
 }
+
+type ClientReserves struct {
+	name      string
+	table     int
+	maxTables int
+
+	// slot is the reserved time, nobody else can sit at the table during it.
+	slot *TimeInterval
+}
+
+func NewClientReserves(name string, table, maxTables int, slot *TimeInterval) *ClientReserves {
+	return &ClientReserves{name: name, table: table, maxTables: maxTables, slot: slot}
+}
+
+func (c *ClientReserves) GetName() string {
+	return c.name
+}
+
+func (c *ClientReserves) GetTable() int {
+	return c.table
+}
+
+func (c *ClientReserves) GetSlot() *TimeInterval {
+	return c.slot
+}
+
+func (c *ClientReserves) String() string {
+	return c.Format("15:04")
+}
+
+// Format returns the client data with the slot in the time format.
+func (c *ClientReserves) Format(timeFormat string) string {
+	return fmt.Sprintf(
+		"%s %d %s %s",
+		c.name, c.table, c.slot.Start.Format(timeFormat), c.slot.End.Format(timeFormat),
+	)
+}
+
+func (c *ClientReserves) Validate() error {
+	if err := apierror.ValidateName(c.name); err != nil {
+		return err
+	}
+
+	if err := apierror.MoreThenZero(c.table); err != nil {
+		return err
+	}
+
+	if err := apierror.NotMoreThen(c.table, c.maxTables); err != nil {
+		return err
+	}
+
+	if c.slot == nil || c.slot.Start.Equal(c.slot.End) {
+		return errors.New(apierror.ErrReservationEmptySlot)
+	}
+
+	return nil
+}
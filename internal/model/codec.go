@@ -0,0 +1,113 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// clientDataJSON is the persisted client data,
+// the type of the event describes, which client data it is.
+type clientDataJSON struct {
+	Type      IncomingEventType `json:"type"`
+	Name      string            `json:"name"`
+	Table     int               `json:"table,omitempty"`
+	MaxTables int               `json:"max_tables,omitempty"`
+	From      *time.Time        `json:"from,omitempty"`
+	To        *time.Time        `json:"to,omitempty"`
+}
+
+// MarshalClientData encodes the client data as JSON, so it can be persisted.
+func MarshalClientData(client ClientData) ([]byte, error) {
+	data, err := newClientDataJSON(client)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(data)
+}
+
+// UnmarshalClientData decodes the client data encoded by MarshalClientData.
+func UnmarshalClientData(data []byte) (ClientData, error) {
+	var decoded clientDataJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+
+	return decoded.clientData()
+}
+
+func newClientDataJSON(client ClientData) (*clientDataJSON, error) {
+	data := &clientDataJSON{Name: client.GetName()}
+	switch c := client.(type) {
+	case *ClientArrives:
+		data.Type = Arrives
+	case *ClientSits:
+		data.Type = Sits
+		data.Table = c.table
+		data.MaxTables = c.maxTables
+	case *ClientWaits:
+		data.Type = Waits
+	case *ClientLeaves:
+		data.Type = Leaves
+	case *ClientReserves:
+		data.Type = Reserves
+		data.Table = c.table
+		data.MaxTables = c.maxTables
+		data.From = &c.slot.Start
+		data.To = &c.slot.End
+	default:
+		return nil, fmt.Errorf("unknown client data: %T", client)
+	}
+
+	return data, nil
+}
+
+func (d *clientDataJSON) clientData() (ClientData, error) {
+	var slot *TimeInterval
+	if d.From != nil && d.To != nil {
+		slot = &TimeInterval{Start: *d.From, End: *d.To}
+	} else if d.Type == Reserves {
+		return nil, fmt.Errorf("reservation has no time slot")
+	}
+
+	client, ok := NewClientData(d.Type, d.Name, d.Table, d.MaxTables, slot)
+	if !ok {
+		return nil, fmt.Errorf("unknown client data type: %d", d.Type)
+	}
+
+	return client, nil
+}
+
+type incomingEventJSON struct {
+	HappensAt time.Time       `json:"happens_at"`
+	Client    *clientDataJSON `json:"client"`
+}
+
+func (e *IncomingEvent) MarshalJSON() ([]byte, error) {
+	client, err := newClientDataJSON(e.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(incomingEventJSON{HappensAt: e.HappensAt, Client: client})
+}
+
+func (e *IncomingEvent) UnmarshalJSON(data []byte) error {
+	var decoded incomingEventJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	if decoded.Client == nil {
+		return fmt.Errorf("event has no client data")
+	}
+
+	client, err := decoded.Client.clientData()
+	if err != nil {
+		return err
+	}
+
+	*e = IncomingEvent{HappensAt: decoded.HappensAt, Type: decoded.Client.Type, Client: client}
+	return nil
+}
@@ -8,6 +8,9 @@ import (
 type WrappedIncomingEvent struct {
 	Event *IncomingEvent
 	Err   error
+
+	// Day is the number of the working day of the event, 0 for the first day.
+	Day int
 }
 
 type IncomingEvent struct {
@@ -32,14 +35,25 @@ func NewIncomingEvent(
 }
 
 func (e *IncomingEvent) String(timeFormat string) string {
+	client := e.Client.String()
+	if formatter, ok := e.Client.(clientTimeFormatter); ok {
+		client = formatter.Format(timeFormat)
+	}
+
 	return fmt.Sprintf(
 		"%s %d %s",
 		e.HappensAt.Format(timeFormat),
 		e.Type,
-		e.Client.String(),
+		client,
 	)
 }
 
+// clientTimeFormatter is the client data with the time,
+// which is printed in the same format as the time of the event.
+type clientTimeFormatter interface {
+	Format(timeFormat string) string
+}
+
 type IncomingEventType int
 
 const (
@@ -47,10 +61,16 @@ const (
 	Sits    IncomingEventType = 2
 	Waits   IncomingEventType = 3
 	Leaves  IncomingEventType = 4
+
+	// Reserves is the booking of the table for the time slot,
+	// the client doesn't have to be in the club.
+	Reserves IncomingEventType = 5
 )
 
 func GetValidClientDataSize(eventType IncomingEventType) int {
 	switch eventType {
+	case Reserves:
+		return 4
 	case Sits:
 		return 2
 	case Waits, Leaves, Arrives:
@@ -17,6 +17,69 @@ func (ti *TimeInterval) In(t time.Time) bool {
 	return ti.Start.Before(t) && ti.End.After(t)
 }
 
+// Overlaps checks, that the intervals have common time.
+func (ti *TimeInterval) Overlaps(other *TimeInterval) bool {
+	return ti.Start.Before(other.End) && other.Start.Before(ti.End)
+}
+
+// Locate returns the moment of the interval, which has the clock time of t.
+//
+// If the interval lasts over midnight, the clock time not later than the end
+// belongs to the next day, otherwise the moment is on the day of the start.
+func (ti *TimeInterval) Locate(t time.Time) time.Time {
+	day := ti.Start
+	if ti.overMidnight() && SecondOfDay(t) <= SecondOfDay(ti.End) {
+		day = ti.End
+	}
+
+	return time.Date(
+		day.Year(), day.Month(), day.Day(),
+		t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), day.Location(),
+	)
+}
+
+// ParseTime parses the clock time in the layout and locates it on the day of the interval,
+// RFC 3339 timestamps are used as is in the location of the interval.
+func (ti *TimeInterval) ParseTime(layout, value string) (time.Time, error) {
+	t, err := time.Parse(layout, value)
+	if err == nil {
+		return ti.Locate(t), nil
+	}
+
+	if t, rfcErr := time.Parse(time.RFC3339, value); rfcErr == nil {
+		return t.In(ti.Start.Location()), nil
+	}
+
+	return time.Time{}, err
+}
+
+// OnDate returns the interval with the same clock time, which starts on the date in the location of the date.
+func (ti *TimeInterval) OnDate(date time.Time) *TimeInterval {
+	at := func(clock time.Time, days int) time.Time {
+		return time.Date(
+			date.Year(), date.Month(), date.Day()+days,
+			clock.Hour(), clock.Minute(), clock.Second(), clock.Nanosecond(), date.Location(),
+		)
+	}
+
+	endDays := 0
+	if ti.overMidnight() {
+		endDays = 1
+	}
+
+	return &TimeInterval{Start: at(ti.Start, 0), End: at(ti.End, endDays)}
+}
+
+// overMidnight checks, that the interval ends on the next day.
+func (ti *TimeInterval) overMidnight() bool {
+	return ti.End.YearDay() != ti.Start.YearDay()
+}
+
+// AddDays returns the same interval days later.
+func (ti *TimeInterval) AddDays(days int) *TimeInterval {
+	return &TimeInterval{Start: ti.Start.AddDate(0, 0, days), End: ti.End.AddDate(0, 0, days)}
+}
+
 func NewTimeInterval(start, end time.Time) *TimeInterval {
 	if start.After(end) {
 		end = end.AddDate(0, 0, 1)
@@ -25,6 +88,80 @@ func NewTimeInterval(start, end time.Time) *TimeInterval {
 	return &TimeInterval{Start: start, End: end}
 }
 
+// TableCategory is a group of tables with their own price, for example, VIP booths.
+type TableCategory struct {
+	Name string
+
+	// PricePerHour overrides the common price for the tables of the category.
+	PricePerHour int
+
+	Tables []int
+}
+
+func NewTableCategory(name string, pricePerHour int, tables []int) *TableCategory {
+	return &TableCategory{
+		Name:         name,
+		PricePerHour: pricePerHour,
+		Tables:       tables,
+	}
+}
+
+// Tariff is the price per hour during the part of the day, for example, evening hours.
+type Tariff struct {
+
+	// From and To are the time of the day, when the tariff is active, To isn't included.
+	//
+	// If To is before From, the tariff lasts over midnight.
+	From time.Time
+	To   time.Time
+
+	PricePerHour int
+
+	// Category is the name of the table category the tariff is applied to,
+	// empty for the tables with the common price.
+	Category string
+}
+
+func NewTariff(from, to time.Time, pricePerHour int, category string) *Tariff {
+	return &Tariff{
+		From:         from,
+		To:           to,
+		PricePerHour: pricePerHour,
+		Category:     category,
+	}
+}
+
+// Overlaps checks, that tariffs are active at the same time of the day.
+func (t *Tariff) Overlaps(other *Tariff) bool {
+	for _, a := range t.daySpans() {
+		for _, b := range other.daySpans() {
+			if a[0] < b[1] && b[0] < a[1] {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// daySpans returns the tariff as the spans of the seconds of the day,
+// a tariff over midnight is split in two.
+func (t *Tariff) daySpans() [][2]int {
+	from, to := SecondOfDay(t.From), SecondOfDay(t.To)
+	if from < to {
+		return [][2]int{{from, to}}
+	}
+
+	return [][2]int{{from, secondsInDay}, {0, to}}
+}
+
+const secondsInDay = 24 * 60 * 60
+
+// SecondOfDay returns the number of seconds passed since the midnight by the clock.
+func SecondOfDay(t time.Time) int {
+	return t.Hour()*60*60 + t.Minute()*60 + t.Second()
+}
+
 // CoreData is the main data that characterizes the computer club.
 //
 // Used in the event processing, calculating revenue, validating events.
@@ -39,7 +176,36 @@ type CoreData struct {
 	PricePerHour int
 
 	// WorkingTime is the time interval when the computer club is opens and closes.
+	//
+	// Without the date in the input it's on the first day of the year 0 in UTC.
 	WorkingTime *TimeInterval
+
+	// Categories are optional groups of tables with their own price.
+	//
+	// Tables, which are not in any category, cost PricePerHour.
+	Categories []*TableCategory
+
+	// Tariffs are optional prices for the part of the day,
+	// out of the tariffs time the table price is used.
+	Tariffs []*Tariff
+
+	// Members are the names of the club members,
+	// they wait for a table before other clients with the priority queue policy.
+	Members []string
+
+	// Accounts are the prepaid balances of the clients at the opening,
+	// clients without the account pay for the sessions as usual.
+	Accounts []*ClientAccount
+}
+
+// ClientAccount is the money a client has paid in advance.
+type ClientAccount struct {
+	Name    string
+	Balance int
+}
+
+func NewClientAccount(name string, balance int) *ClientAccount {
+	return &ClientAccount{Name: name, Balance: balance}
 }
 
 func NewCoreData(tablesCount, pricePerHour int, workingTime *TimeInterval) *CoreData {
@@ -49,3 +215,64 @@ func NewCoreData(tablesCount, pricePerHour int, workingTime *TimeInterval) *Core
 		WorkingTime:  workingTime,
 	}
 }
+
+// CategoryOf returns the category of the table, nil if the table has the common price.
+func (c *CoreData) CategoryOf(table int) *TableCategory {
+	for _, category := range c.Categories {
+		for _, t := range category.Tables {
+			if t == table {
+				return category
+			}
+		}
+	}
+
+	return nil
+}
+
+// PriceOf returns the price per hour of playing on the table.
+func (c *CoreData) PriceOf(table int) int {
+	if category := c.CategoryOf(table); category != nil {
+		return category.PricePerHour
+	}
+
+	return c.PricePerHour
+}
+
+// IsMember checks, that the client is a member of the club.
+func (c *CoreData) IsMember(name string) bool {
+	for _, member := range c.Members {
+		if member == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AccountOf returns the account of the client, nil if the client has no account.
+func (c *CoreData) AccountOf(name string) *ClientAccount {
+	for _, account := range c.Accounts {
+		if account.Name == name {
+			return account
+		}
+	}
+
+	return nil
+}
+
+// TariffsOf returns the tariffs applied to the table.
+func (c *CoreData) TariffsOf(table int) []*Tariff {
+	var categoryName string
+	if category := c.CategoryOf(table); category != nil {
+		categoryName = category.Name
+	}
+
+	var tariffs []*Tariff
+	for _, tariff := range c.Tariffs {
+		if tariff.Category == categoryName {
+			tariffs = append(tariffs, tariff)
+		}
+	}
+
+	return tariffs
+}
@@ -66,10 +66,21 @@ func NewClientSatEvent(happensAt time.Time, client ClientData) *OutgoingEvent {
 	}
 }
 
+// NewClientOutOfBalanceEvent is generated, when the prepaid balance of the seated client is exhausted,
+// the client leaves the club.
+func NewClientOutOfBalanceEvent(happensAt time.Time, client ClientData) *OutgoingEvent {
+	return &OutgoingEvent{
+		HappensAt: happensAt,
+		Type:      OutgoingEventTypeClientOutOfBalance,
+		Client:    client,
+	}
+}
+
 type OutgoingEventType int
 
 const (
-	OutgoingEventTypeClientLeft OutgoingEventType = 11
-	OutgoingEventTypeClientSat  OutgoingEventType = 12
-	OutgoingEventTypeError      OutgoingEventType = 13
+	OutgoingEventTypeClientLeft         OutgoingEventType = 11
+	OutgoingEventTypeClientSat          OutgoingEventType = 12
+	OutgoingEventTypeError              OutgoingEventType = 13
+	OutgoingEventTypeClientOutOfBalance OutgoingEventType = 14
 )
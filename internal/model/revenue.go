@@ -16,10 +16,47 @@ type RevenueStats struct {
 	// When calculating the income, we round table usage time up to the nearest hour.
 	// UsageTime isn't rounded.
 	UsageTime time.Duration
+
+	// Sessions is the number of times the table was occupied and released.
+	Sessions int
+
+	// FirstOccupied is the start of the first session at the table.
+	FirstOccupied time.Time
+
+	// LastReleased is the end of the last session at the table.
+	LastReleased time.Time
 }
 
 func (r RevenueStats) String() string {
-	hours := int(r.UsageTime.Hours())
-	minutes := int(r.UsageTime.Minutes()) % 60
-	return fmt.Sprintf("%d %02d:%02d", r.Income, hours, minutes)
+	return r.Format("15:04")
+}
+
+// Format returns the income and the usage time with the precision of the time format.
+func (r RevenueStats) Format(timeFormat string) string {
+	return fmt.Sprintf("%d %s", r.Income, r.UsageClock(timeFormat))
+}
+
+// UsageClock returns the usage time in the "HH:MM" format,
+// "HH:MM:SS", if the time format has seconds.
+func (r RevenueStats) UsageClock(timeFormat string) string {
+	return FormatDuration(r.UsageTime, timeFormat)
+}
+
+// FormatDuration returns the duration in the "HH:MM" format,
+// "HH:MM:SS", if the time format has seconds.
+func FormatDuration(d time.Duration, timeFormat string) string {
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	if !HasSeconds(timeFormat) {
+		return fmt.Sprintf("%02d:%02d", hours, minutes)
+	}
+
+	seconds := int(d.Seconds()) % 60
+	return fmt.Sprintf("%02d:%02d:%02d", hours, minutes, seconds)
+}
+
+// HasSeconds checks, that the time format shows the seconds.
+func HasSeconds(timeFormat string) bool {
+	t := time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC)
+	return t.Format(timeFormat) != t.Add(time.Second).Format(timeFormat)
 }
@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// Session is the time a client spent at the table without leaving it.
+type Session struct {
+	Client string
+	Table  int
+
+	// From is the time, when the client sat at the table,
+	// To is the time, when the client left it or moved to another table.
+	From time.Time
+	To   time.Time
+
+	// Billed is the usage time the client pays for, after the rounding of the billing policy.
+	Billed time.Duration
+
+	// Amount is the money the client pays for the session.
+	Amount int
+}
+
+func NewSession(client string, table int, from, to time.Time, billed time.Duration, amount int) *Session {
+	return &Session{
+		Client: client,
+		Table:  table,
+		From:   from,
+		To:     to,
+		Billed: billed,
+		Amount: amount,
+	}
+}
+
+// UsageTime returns the time spent at the table.
+func (s *Session) UsageTime() time.Duration {
+	return s.To.Sub(s.From)
+}
@@ -0,0 +1,59 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"yadro-intern/internal/apierror"
+)
+
+const (
+	errorKindParse      = "parse"
+	errorKindValidation = "validation"
+)
+
+type jsonError struct {
+	Row     int    `json:"row"`
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// WriteErrors writes the input format errors in the format,
+// text format writes every error as a separate line.
+func WriteErrors(out io.Writer, format string, errs []error) error {
+	if format != FormatJSON {
+		for _, err := range errs {
+			if _, e := fmt.Fprintln(out, err); e != nil {
+				return e
+			}
+		}
+
+		return nil
+	}
+
+	report := make([]jsonError, 0, len(errs))
+	for _, err := range errs {
+		report = append(report, newJSONError(err))
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+func newJSONError(err error) jsonError {
+	var (
+		parseErr      *apierror.ParseError
+		validationErr *apierror.ValidationError
+	)
+
+	switch {
+	case errors.As(err, &parseErr):
+		return jsonError{Row: parseErr.RowNumber, Kind: errorKindParse, Message: parseErr.UserMsg}
+	case errors.As(err, &validationErr):
+		return jsonError{Row: validationErr.RowNumber, Kind: errorKindValidation, Message: validationErr.UserMsg}
+	}
+
+	return jsonError{Message: err.Error()}
+}
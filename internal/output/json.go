@@ -0,0 +1,163 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+	"yadro-intern/internal/model"
+)
+
+const (
+	directionIncoming = "incoming"
+	directionOutgoing = "outgoing"
+)
+
+type jsonReport struct {
+	Start      string         `json:"start"`
+	End        string         `json:"end"`
+	Events     []JSONEvent    `json:"events"`
+	Revenue    []jsonRevenue  `json:"revenue"`
+	Categories []jsonCategory `json:"categories,omitempty"`
+}
+
+// JSONEvent is the incoming or outgoing event in the JSON output.
+type JSONEvent struct {
+	Time      string `json:"time"`
+	Direction string `json:"direction"`
+	Type      int    `json:"type"`
+	Client    string `json:"client,omitempty"`
+	Table     int    `json:"table,omitempty"`
+	Error     string `json:"error,omitempty"`
+
+	// From and To are the reserved time of the reservation.
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+}
+
+type jsonRevenue struct {
+	Table        int    `json:"table"`
+	Category     string `json:"category,omitempty"`
+	Income       int    `json:"income"`
+	UsageTime    string `json:"usage_time"`
+	UsageMinutes int    `json:"usage_minutes"`
+}
+
+type jsonCategory struct {
+	Category     string `json:"category"`
+	Income       int    `json:"income"`
+	UsageTime    string `json:"usage_time"`
+	UsageMinutes int    `json:"usage_minutes"`
+}
+
+// JSONFormatter collects all results and writes them as a single JSON document on flush,
+// results of every working day are written as a separate document.
+type JSONFormatter struct {
+	out        io.Writer
+	timeFormat string
+	report     jsonReport
+}
+
+func NewJSONFormatter(out io.Writer, timeFormat string) *JSONFormatter {
+	return &JSONFormatter{
+		out:        out,
+		timeFormat: timeFormat,
+		report:     newJSONReport(),
+	}
+}
+
+func newJSONReport() jsonReport {
+	return jsonReport{
+		Events:  make([]JSONEvent, 0),
+		Revenue: make([]jsonRevenue, 0),
+	}
+}
+
+func (f *JSONFormatter) WriteStart(t time.Time) {
+	f.report.Start = t.Format(f.timeFormat)
+}
+
+func (f *JSONFormatter) WriteIncoming(event *model.IncomingEvent) {
+	f.report.Events = append(f.report.Events, NewIncomingJSONEvent(event, f.timeFormat))
+}
+
+func (f *JSONFormatter) WriteOutgoing(event *model.OutgoingEvent) {
+	f.report.Events = append(f.report.Events, NewOutgoingJSONEvent(event, f.timeFormat))
+}
+
+func (f *JSONFormatter) WriteEnd(t time.Time) {
+	f.report.End = t.Format(f.timeFormat)
+}
+
+func (f *JSONFormatter) WriteTableRevenue(table int, category string, stats *model.RevenueStats) {
+	f.report.Revenue = append(f.report.Revenue, jsonRevenue{
+		Table:        table,
+		Category:     category,
+		Income:       stats.Income,
+		UsageTime:    stats.UsageClock(f.timeFormat),
+		UsageMinutes: int(stats.UsageTime.Minutes()),
+	})
+}
+
+func (f *JSONFormatter) WriteCategoryRevenue(category string, stats *model.RevenueStats) {
+	f.report.Categories = append(f.report.Categories, jsonCategory{
+		Category:     category,
+		Income:       stats.Income,
+		UsageTime:    stats.UsageClock(f.timeFormat),
+		UsageMinutes: int(stats.UsageTime.Minutes()),
+	})
+}
+
+func (f *JSONFormatter) Flush() error {
+	encoder := json.NewEncoder(f.out)
+	encoder.SetIndent("", "  ")
+
+	report := f.report
+	f.report = newJSONReport()
+	return encoder.Encode(report)
+}
+
+func NewIncomingJSONEvent(event *model.IncomingEvent, timeFormat string) JSONEvent {
+	e := JSONEvent{
+		Time:      event.HappensAt.Format(timeFormat),
+		Direction: directionIncoming,
+		Type:      int(event.Type),
+		Client:    event.Client.GetName(),
+		Table:     tableOf(event.Client),
+	}
+
+	if reserves, ok := event.Client.(*model.ClientReserves); ok {
+		e.From = reserves.GetSlot().Start.Format(timeFormat)
+		e.To = reserves.GetSlot().End.Format(timeFormat)
+	}
+
+	return e
+}
+
+func NewOutgoingJSONEvent(event *model.OutgoingEvent, timeFormat string) JSONEvent {
+	e := JSONEvent{
+		Time:      event.HappensAt.Format(timeFormat),
+		Direction: directionOutgoing,
+		Type:      int(event.Type),
+	}
+
+	if event.Err != nil {
+		e.Error = event.Err.Error()
+	} else {
+		e.Client = event.Client.GetName()
+		e.Table = tableOf(event.Client)
+	}
+
+	return e
+}
+
+// tableOf returns the table of the client data, 0 if the data has no table.
+func tableOf(client model.ClientData) int {
+	switch c := client.(type) {
+	case *model.ClientSits:
+		return c.GetTable()
+	case *model.ClientReserves:
+		return c.GetTable()
+	}
+
+	return 0
+}
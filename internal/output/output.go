@@ -0,0 +1,60 @@
+package output
+
+import (
+	"io"
+	"time"
+	"yadro-intern/internal/model"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Formatter writes the results of the events processing in some format.
+type Formatter interface {
+
+	// WriteStart writes the time when the computer club opens.
+	WriteStart(t time.Time)
+
+	// WriteIncoming writes the event read from the input.
+	WriteIncoming(event *model.IncomingEvent)
+
+	// WriteOutgoing writes the event generated while processing.
+	WriteOutgoing(event *model.OutgoingEvent)
+
+	// WriteEnd writes the time when the computer club closes.
+	WriteEnd(t time.Time)
+
+	// WriteTableRevenue writes the revenue of the table,
+	// category is empty for the tables with the common price.
+	WriteTableRevenue(table int, category string, stats *model.RevenueStats)
+
+	// WriteCategoryRevenue writes the revenue of all tables of the category.
+	WriteCategoryRevenue(category string, stats *model.RevenueStats)
+
+	// Flush writes everything, which is kept by the formatter.
+	//
+	// Called, when all results of the working day are written.
+	Flush() error
+}
+
+// IsKnownFormat checks, that the formatter of the format exists.
+func IsKnownFormat(format string) bool {
+	return format == FormatText || format == FormatJSON
+}
+
+// NewFormatter returns the formatter of the format, text formatter is used for unknown ones.
+//
+// If out is nil, nothing is written.
+func NewFormatter(format string, out io.Writer, timeFormat string) Formatter {
+	if out == nil {
+		out = io.Discard
+	}
+
+	if format == FormatJSON {
+		return NewJSONFormatter(out, timeFormat)
+	}
+
+	return NewTextFormatter(out, timeFormat)
+}
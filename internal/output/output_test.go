@@ -0,0 +1,112 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+	"yadro-intern/internal/apierror"
+	"yadro-intern/internal/model"
+)
+
+type outputSuite struct {
+	suite.Suite
+}
+
+func TestOutputSuite(t *testing.T) {
+	suite.Run(t, new(outputSuite))
+}
+
+func writeDay(f Formatter) {
+	f.WriteStart(time.Date(0, 0, 0, 9, 0, 0, 0, time.UTC))
+	f.WriteIncoming(model.NewIncomingEvent(
+		time.Date(0, 0, 0, 9, 30, 0, 0, time.UTC),
+		model.Sits,
+		model.NewClientSits("client1", 1, 2),
+	))
+	f.WriteOutgoing(model.NewErrorEvent(
+		time.Date(0, 0, 0, 9, 30, 0, 0, time.UTC),
+		errors.New(apierror.ErrClientUnknown),
+	))
+	f.WriteOutgoing(model.NewClientLeftEvent(
+		time.Date(0, 0, 0, 19, 0, 0, 0, time.UTC),
+		model.NewClientLeaves("client2"),
+	))
+	f.WriteEnd(time.Date(0, 0, 0, 19, 0, 0, 0, time.UTC))
+	f.WriteTableRevenue(1, "vip", &model.RevenueStats{Income: 30, UsageTime: 90 * time.Minute})
+	f.WriteCategoryRevenue("vip", &model.RevenueStats{Income: 30, UsageTime: 90 * time.Minute})
+}
+
+func (s *outputSuite) TestTextFormatter() {
+	var buf bytes.Buffer
+	f := NewFormatter(FormatText, &buf, "15:04")
+
+	writeDay(f)
+	s.NoError(f.Flush())
+	s.Equal(`09:00
+09:30 2 client1 1
+09:30 13 ClientUnknown
+19:00 11 client2
+19:00
+1 30 01:30
+vip 30 01:30
+`, buf.String())
+}
+
+func (s *outputSuite) TestJSONFormatter() {
+	var buf bytes.Buffer
+	f := NewFormatter(FormatJSON, &buf, "15:04")
+
+	writeDay(f)
+	s.Empty(buf.String(), "json is written on flush")
+
+	s.NoError(f.Flush())
+	s.JSONEq(`{
+  "start": "09:00",
+  "end": "19:00",
+  "events": [
+    {"time": "09:30", "direction": "incoming", "type": 2, "client": "client1", "table": 1},
+    {"time": "09:30", "direction": "outgoing", "type": 13, "error": "ClientUnknown"},
+    {"time": "19:00", "direction": "outgoing", "type": 11, "client": "client2"}
+  ],
+  "revenue": [
+    {"table": 1, "category": "vip", "income": 30, "usage_time": "01:30", "usage_minutes": 90}
+  ],
+  "categories": [
+    {"category": "vip", "income": 30, "usage_time": "01:30", "usage_minutes": 90}
+  ]
+}`, buf.String())
+}
+
+func (s *outputSuite) TestNilWriter() {
+	for _, format := range []string{FormatText, FormatJSON} {
+		f := NewFormatter(format, nil, "15:04")
+		writeDay(f)
+		s.NoError(f.Flush())
+	}
+}
+
+func (s *outputSuite) TestWriteErrors() {
+	errs := []error{
+		&apierror.ParseError{RowNumber: 1, UserMsg: apierror.ErrTablesCountInvalidFormat},
+		&apierror.ValidationError{RowNumber: 5, UserMsg: apierror.ErrUnknownEventType},
+	}
+
+	var text bytes.Buffer
+	s.NoError(WriteErrors(&text, FormatText, errs))
+	s.Equal(`failed to parse row 1: tables count are not integer
+validation error at row 5: unknown event type
+`, text.String())
+
+	var js bytes.Buffer
+	s.NoError(WriteErrors(&js, FormatJSON, errs))
+	s.JSONEq(`[
+  {"row": 1, "kind": "parse", "message": "tables count are not integer"},
+  {"row": 5, "kind": "validation", "message": "unknown event type"}
+]`, js.String())
+
+	var empty bytes.Buffer
+	s.NoError(WriteErrors(&empty, FormatJSON, nil))
+	s.JSONEq(`[]`, empty.String())
+}
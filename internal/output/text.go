@@ -0,0 +1,50 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"time"
+	"yadro-intern/internal/model"
+)
+
+// TextFormatter writes every result as a separate line, as soon as it's written.
+type TextFormatter struct {
+	out        io.Writer
+	timeFormat string
+}
+
+func NewTextFormatter(out io.Writer, timeFormat string) *TextFormatter {
+	return &TextFormatter{out: out, timeFormat: timeFormat}
+}
+
+func (f *TextFormatter) WriteStart(t time.Time) {
+	f.writeLine(t.Format(f.timeFormat))
+}
+
+func (f *TextFormatter) WriteIncoming(event *model.IncomingEvent) {
+	f.writeLine(event.String(f.timeFormat))
+}
+
+func (f *TextFormatter) WriteOutgoing(event *model.OutgoingEvent) {
+	f.writeLine(event.String(f.timeFormat))
+}
+
+func (f *TextFormatter) WriteEnd(t time.Time) {
+	f.writeLine(t.Format(f.timeFormat))
+}
+
+func (f *TextFormatter) WriteTableRevenue(table int, _ string, stats *model.RevenueStats) {
+	f.writeLine(fmt.Sprintf("%d %s", table, stats.Format(f.timeFormat)))
+}
+
+func (f *TextFormatter) WriteCategoryRevenue(category string, stats *model.RevenueStats) {
+	f.writeLine(fmt.Sprintf("%s %s", category, stats.Format(f.timeFormat)))
+}
+
+func (f *TextFormatter) Flush() error {
+	return nil
+}
+
+func (f *TextFormatter) writeLine(line string) {
+	_, _ = io.WriteString(f.out, line+"\n")
+}
@@ -2,6 +2,8 @@ package parser
 
 import (
 	"bufio"
+	"errors"
+	"math"
 	"strconv"
 	"strings"
 	"time"
@@ -10,15 +12,40 @@ import (
 	"yadro-intern/internal/model"
 )
 
+// header directives are optional lines after the core data,
+// they start with a keyword, so they can't be confused with events.
+const (
+	categoryDirective = "category"
+	tariffDirective   = "tariff"
+	memberDirective   = "member"
+	dateDirective     = "date"
+	balanceDirective  = "balance"
+)
+
+// dateLayout is the layout of the date directive.
+const dateLayout = "2006-01-02"
+
+// dayDelimiter is the line between the events of consecutive working days.
+const dayDelimiter = "day"
+
 type Parser interface {
 
-	// ReadCoreData reads important data needed for further parsing.
+	// ReadCoreData reads important data needed for further parsing,
+	// including optional header directives.
 	ReadCoreData() (*model.CoreData, error)
 
+	// ReadHeader and ReadDirectives read the same data as ReadCoreData in two steps,
+	// so the first three lines of the header are known before the directives are read.
+	ReadHeader() (*model.CoreData, error)
+	ReadDirectives(coreData *model.CoreData) error
+
 	// ReadEvents reads events from the file in a separate goroutine.
-	// It returns a channel of events.
+	// It returns a channel of events in chronological order.
 	// The channel is closed when all events are read, or when an error occurs.
 	ReadEvents(maxTables int) <-chan model.WrappedIncomingEvent
+
+	// Validate reads the whole input and returns all format errors.
+	Validate() []error
 }
 
 type FileParser struct {
@@ -26,7 +53,25 @@ type FileParser struct {
 	cfg       *config.Parser
 	rowNumber int
 
+	// unread is set, when the current line is scanned, but not consumed yet,
+	// the next scan returns it again.
+	unread bool
+
 	maxTables int
+
+	// workingTime is the working time of the first day,
+	// event times are located relative to the working time of their day.
+	workingTime *model.TimeInterval
+
+	// day is the number of the working day of the read events.
+	day int
+
+	// dated is set, when the date directive is read.
+	dated bool
+
+	// lastEvent is the latest event sent to the events channel,
+	// events happened before it can't be sent anymore.
+	lastEvent *model.IncomingEvent
 }
 
 func NewFileParser(scanner *bufio.Scanner, cfg *config.Parser) *FileParser {
@@ -37,6 +82,21 @@ func NewFileParser(scanner *bufio.Scanner, cfg *config.Parser) *FileParser {
 }
 
 func (p *FileParser) ReadCoreData() (*model.CoreData, error) {
+	coreData, err := p.ReadHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = p.ReadDirectives(coreData); err != nil {
+		return nil, err
+	}
+
+	return coreData, nil
+}
+
+// ReadHeader reads the first three lines of the header without the directives,
+// they are read by ReadDirectives.
+func (p *FileParser) ReadHeader() (*model.CoreData, error) {
 	tablesCount, err := p.readTablesCount(apierror.MoreThenZero)
 	if err != nil {
 		return nil, err
@@ -55,6 +115,12 @@ func (p *FileParser) ReadCoreData() (*model.CoreData, error) {
 	return model.NewCoreData(tablesCount, pricePerHour, workingTime), nil
 }
 
+// ReadDirectives reads the directives of the header after ReadHeader,
+// the header ends at the first line, which isn't a directive.
+func (p *FileParser) ReadDirectives(coreData *model.CoreData) error {
+	return p.readDirectives(coreData)
+}
+
 func (p *FileParser) ReadEvents(maxTables int) <-chan model.WrappedIncomingEvent {
 	eventsChan := make(chan model.WrappedIncomingEvent, p.cfg.EventsChanSize)
 	p.maxTables = maxTables
@@ -62,25 +128,508 @@ func (p *FileParser) ReadEvents(maxTables int) <-chan model.WrappedIncomingEvent
 	go func() {
 		defer close(eventsChan)
 
-		for p.scanWithRowNumber() {
-			event, err := p.readEvent()
-			if err != nil {
+		p.scanEvents(
+			func(event *model.IncomingEvent, day int) {
+				eventsChan <- model.WrappedIncomingEvent{Event: event, Day: day}
+			},
+			func(err error) bool {
 				eventsChan <- model.WrappedIncomingEvent{Err: err}
+				return false
+			},
+		)
+	}()
+
+	return eventsChan
+}
+
+// Validate reads the whole input and returns all format errors,
+// unlike ReadCoreData and ReadEvents it doesn't stop at the first one.
+//
+// Header values with errors are replaced by the permissive ones,
+// so they don't cause errors in the following rows.
+func (p *FileParser) Validate() []error {
+	var errs []error
+
+	tablesCount, err := p.readTablesCount(apierror.MoreThenZero)
+	if err != nil {
+		errs = append(errs, err)
+		tablesCount = math.MaxInt
+	}
+
+	workingTime, err := p.readWorkingTime()
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	pricePerHour, err := p.readPricePerHour(apierror.MoreThenZero)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	coreData := model.NewCoreData(tablesCount, pricePerHour, workingTime)
+	for p.scanDirective() {
+		if err = p.readDirective(coreData); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	p.maxTables = tablesCount
+	p.scanEvents(
+		func(*model.IncomingEvent, int) {},
+		func(err error) bool {
+			errs = append(errs, err)
+			return true
+		},
+	)
+
+	return errs
+}
+
+// scanEvents reads events and passes them to onEvent in chronological order with the number of their day,
+// onError decides, whether reading goes on after the error.
+func (p *FileParser) scanEvents(onEvent func(*model.IncomingEvent, int), onError func(error) bool) {
+	// in strict mode window is empty, so events are passed as soon as they are read
+	windowSize := 0
+	if p.cfg.LenientOrdering {
+		windowSize = p.cfg.ReorderWindowSize
+	}
+
+	emit := func(event *model.IncomingEvent, day int) {
+		p.lastEvent = event
+		onEvent(event, day)
+	}
+
+	window := make(reorderWindow, 0, windowSize+1)
+	for p.scanWithRowNumber() {
+		if p.scanner.Text() == dayDelimiter {
+			p.day++
+			continue
+		}
+
+		event, err := p.readEvent()
+		if err != nil {
+			if !onError(err) {
 				return
 			}
 
-			eventsChan <- model.WrappedIncomingEvent{Event: event}
+			continue
 		}
-	}()
 
-	return eventsChan
+		window.push(event, p.rowNumber, p.day)
+		if window.Len() > windowSize {
+			emit(window.pop())
+		}
+	}
+
+	for window.Len() > 0 {
+		emit(window.pop())
+	}
 }
 
 func (p *FileParser) scanWithRowNumber() bool {
+	if p.unread {
+		p.unread = false
+		return true
+	}
+
 	p.rowNumber++
 	return p.scanner.Scan()
 }
 
+// unscan makes the next scan return the current line again.
+func (p *FileParser) unscan() {
+	p.unread = true
+}
+
+func (p *FileParser) readDirectives(coreData *model.CoreData) error {
+	for p.scanDirective() {
+		if err := p.readDirective(coreData); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scanDirective scans the next line, if it's a header directive.
+func (p *FileParser) scanDirective() bool {
+	if !p.scanWithRowNumber() {
+		return false
+	}
+
+	keyword, _, _ := strings.Cut(p.scanner.Text(), p.cfg.EventInfoSeparator)
+	switch keyword {
+	case categoryDirective, tariffDirective, memberDirective, dateDirective, balanceDirective:
+		return true
+	}
+
+	p.unscan()
+	return false
+}
+
+func (p *FileParser) readDirective(coreData *model.CoreData) error {
+	fields := strings.Split(p.scanner.Text(), p.cfg.EventInfoSeparator)
+	switch fields[0] {
+	case categoryDirective:
+		category, err := p.readCategory(fields[1:], coreData)
+		if err != nil {
+			return err
+		}
+
+		coreData.Categories = append(coreData.Categories, category)
+	case tariffDirective:
+		tariff, err := p.readTariff(fields[1:], coreData)
+		if err != nil {
+			return err
+		}
+
+		coreData.Tariffs = append(coreData.Tariffs, tariff)
+	case memberDirective:
+		members, err := p.readMembers(fields[1:], coreData)
+		if err != nil {
+			return err
+		}
+
+		coreData.Members = append(coreData.Members, members...)
+	case balanceDirective:
+		account, err := p.readBalance(fields[1:], coreData)
+		if err != nil {
+			return err
+		}
+
+		coreData.Accounts = append(coreData.Accounts, account)
+	case dateDirective:
+		date, err := p.readDate(fields[1:])
+		if err != nil {
+			return err
+		}
+
+		// working time isn't set, when it has errors in the validation
+		if coreData.WorkingTime != nil {
+			coreData.WorkingTime = coreData.WorkingTime.OnDate(date)
+			p.workingTime = coreData.WorkingTime
+		}
+	}
+
+	return nil
+}
+
+func (p *FileParser) readBalance(args []string, coreData *model.CoreData) (*model.ClientAccount, error) {
+	if len(args) != 2 {
+		return nil, &apierror.ParseError{
+			RowNumber: p.rowNumber,
+			UserMsg:   apierror.ErrBalanceInvalidFormat,
+		}
+	}
+	name, amountStr := args[0], args[1]
+
+	if e := apierror.ValidateName(name); e != nil {
+		return nil, &apierror.ValidationError{
+			RowNumber: p.rowNumber,
+			UserMsg:   e.Error(),
+		}
+	}
+
+	if coreData.AccountOf(name) != nil {
+		return nil, &apierror.ValidationError{
+			RowNumber: p.rowNumber,
+			UserMsg:   apierror.ErrBalanceDuplicated,
+		}
+	}
+
+	amount, err := strconv.Atoi(amountStr)
+	if err != nil {
+		return nil, &apierror.ParseError{
+			RowNumber: p.rowNumber,
+			UserMsg:   apierror.ErrFailedToParseBalance,
+			BaseErr:   err,
+		}
+	}
+
+	if e := apierror.MoreThenZero(amount); e != nil {
+		return nil, &apierror.ValidationError{
+			RowNumber: p.rowNumber,
+			UserMsg:   e.Error(),
+		}
+	}
+
+	return model.NewClientAccount(name, amount), nil
+}
+
+func (p *FileParser) readDate(args []string) (time.Time, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return time.Time{}, &apierror.ParseError{
+			RowNumber: p.rowNumber,
+			UserMsg:   apierror.ErrDateInvalidFormat,
+		}
+	}
+
+	if p.dated {
+		return time.Time{}, &apierror.ValidationError{
+			RowNumber: p.rowNumber,
+			UserMsg:   apierror.ErrDateDuplicated,
+		}
+	}
+
+	location := time.UTC
+	if len(args) == 2 {
+		var err error
+		if location, err = time.LoadLocation(args[1]); err != nil {
+			return time.Time{}, &apierror.ValidationError{
+				RowNumber: p.rowNumber,
+				UserMsg:   apierror.ErrUnknownTimezone,
+			}
+		}
+	}
+
+	date, err := time.ParseInLocation(dateLayout, args[0], location)
+	if err != nil {
+		return time.Time{}, &apierror.ParseError{
+			RowNumber: p.rowNumber,
+			UserMsg:   apierror.ErrFailedToParseDate,
+			BaseErr:   err,
+		}
+	}
+
+	p.dated = true
+	return date, nil
+}
+
+func (p *FileParser) readCategory(args []string, coreData *model.CoreData) (*model.TableCategory, error) {
+	if len(args) != 3 {
+		return nil, &apierror.ParseError{
+			RowNumber: p.rowNumber,
+			UserMsg:   apierror.ErrTableCategoryInvalidFormat,
+		}
+	}
+	name, priceStr, tablesStr := args[0], args[1], args[2]
+
+	if e := apierror.ValidateCategoryName(name); e != nil {
+		return nil, &apierror.ValidationError{
+			RowNumber: p.rowNumber,
+			UserMsg:   e.Error(),
+		}
+	}
+
+	if hasCategory(coreData, name) {
+		return nil, &apierror.ValidationError{
+			RowNumber: p.rowNumber,
+			UserMsg:   apierror.ErrTableCategoryDuplicated,
+		}
+	}
+
+	price, err := strconv.Atoi(priceStr)
+	if err != nil {
+		return nil, &apierror.ParseError{
+			RowNumber: p.rowNumber,
+			UserMsg:   apierror.ErrFailedToParseCategoryPrice,
+			BaseErr:   err,
+		}
+	}
+
+	if e := apierror.MoreThenZero(price); e != nil {
+		return nil, &apierror.ValidationError{
+			RowNumber: p.rowNumber,
+			UserMsg:   e.Error(),
+		}
+	}
+
+	tables, err := p.parseTables(tablesStr, coreData)
+	if err != nil {
+		return nil, err
+	}
+
+	return model.NewTableCategory(name, price, tables), nil
+}
+
+func (p *FileParser) readMembers(args []string, coreData *model.CoreData) ([]string, error) {
+	if len(args) == 0 {
+		return nil, &apierror.ParseError{
+			RowNumber: p.rowNumber,
+			UserMsg:   apierror.ErrMemberInvalidFormat,
+		}
+	}
+
+	members := make([]string, 0, len(args))
+	for _, name := range args {
+		if e := apierror.ValidateName(name); e != nil {
+			return nil, &apierror.ValidationError{
+				RowNumber: p.rowNumber,
+				UserMsg:   e.Error(),
+			}
+		}
+
+		if coreData.IsMember(name) || contains(members, name) {
+			return nil, &apierror.ValidationError{
+				RowNumber: p.rowNumber,
+				UserMsg:   apierror.ErrMemberDuplicated,
+			}
+		}
+
+		members = append(members, name)
+	}
+
+	return members, nil
+}
+
+func (p *FileParser) readTariff(args []string, coreData *model.CoreData) (*model.Tariff, error) {
+	if len(args) != 3 && len(args) != 4 {
+		return nil, &apierror.ParseError{
+			RowNumber: p.rowNumber,
+			UserMsg:   apierror.ErrTariffInvalidFormat,
+		}
+	}
+
+	from, err := time.Parse(p.cfg.TimeFormat, args[0])
+	if err != nil {
+		return nil, &apierror.ParseError{
+			RowNumber: p.rowNumber,
+			UserMsg:   apierror.ErrFailedToParseTariffTime,
+			BaseErr:   err,
+		}
+	}
+
+	to, err := time.Parse(p.cfg.TimeFormat, args[1])
+	if err != nil {
+		return nil, &apierror.ParseError{
+			RowNumber: p.rowNumber,
+			UserMsg:   apierror.ErrFailedToParseTariffTime,
+			BaseErr:   err,
+		}
+	}
+
+	if from.Equal(to) {
+		return nil, &apierror.ValidationError{
+			RowNumber: p.rowNumber,
+			UserMsg:   apierror.ErrTariffEmptyInterval,
+		}
+	}
+
+	price, err := strconv.Atoi(args[2])
+	if err != nil {
+		return nil, &apierror.ParseError{
+			RowNumber: p.rowNumber,
+			UserMsg:   apierror.ErrFailedToParseTariffPrice,
+			BaseErr:   err,
+		}
+	}
+
+	if e := apierror.MoreThenZero(price); e != nil {
+		return nil, &apierror.ValidationError{
+			RowNumber: p.rowNumber,
+			UserMsg:   e.Error(),
+		}
+	}
+
+	var category string
+	if len(args) == 4 {
+		category = args[3]
+		if !hasCategory(coreData, category) {
+			return nil, &apierror.ValidationError{
+				RowNumber: p.rowNumber,
+				UserMsg:   apierror.ErrTariffUnknownCategory,
+			}
+		}
+	}
+
+	tariff := model.NewTariff(from, to, price, category)
+	for _, other := range coreData.Tariffs {
+		if other.Category == tariff.Category && other.Overlaps(tariff) {
+			return nil, &apierror.ValidationError{
+				RowNumber: p.rowNumber,
+				UserMsg:   apierror.ErrTariffOverlaps,
+			}
+		}
+	}
+
+	return tariff, nil
+}
+
+func hasCategory(coreData *model.CoreData, name string) bool {
+	for _, category := range coreData.Categories {
+		if category.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseTables parses comma separated table numbers and ranges,
+// for example, "1-3,5" means tables 1, 2, 3 and 5.
+func (p *FileParser) parseTables(s string, coreData *model.CoreData) ([]int, error) {
+	var tables []int
+	for _, part := range strings.Split(s, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		if !isRange {
+			to = from
+		}
+
+		first, err := strconv.Atoi(from)
+		if err != nil {
+			return nil, &apierror.ParseError{
+				RowNumber: p.rowNumber,
+				UserMsg:   apierror.ErrFailedToParseCategoryTables,
+				BaseErr:   err,
+			}
+		}
+
+		last, err := strconv.Atoi(to)
+		if err != nil || last < first {
+			return nil, &apierror.ParseError{
+				RowNumber: p.rowNumber,
+				UserMsg:   apierror.ErrFailedToParseCategoryTables,
+				BaseErr:   err,
+			}
+		}
+
+		for table := first; table <= last; table++ {
+			if e := p.validateCategoryTable(table, tables, coreData); e != nil {
+				return nil, &apierror.ValidationError{
+					RowNumber: p.rowNumber,
+					UserMsg:   e.Error(),
+				}
+			}
+
+			tables = append(tables, table)
+		}
+	}
+
+	return tables, nil
+}
+
+func (p *FileParser) validateCategoryTable(table int, categoryTables []int, coreData *model.CoreData) error {
+	if err := apierror.MoreThenZero(table); err != nil {
+		return err
+	}
+
+	if err := apierror.NotMoreThen(table, coreData.TablesCount); err != nil {
+		return err
+	}
+
+	if coreData.CategoryOf(table) != nil {
+		return errors.New(apierror.ErrTableAlreadyCategorized)
+	}
+
+	for _, t := range categoryTables {
+		if t == table {
+			return errors.New(apierror.ErrTableAlreadyCategorized)
+		}
+	}
+
+	return nil
+}
+
 func (p *FileParser) readTablesCount(validate apierror.ValidationFn[int]) (int, error) {
 	if !p.scanWithRowNumber() {
 		return 0, &apierror.ParseError{
@@ -170,9 +719,19 @@ func (p *FileParser) readWorkingTime() (*model.TimeInterval, error) {
 	}
 
 	ti := model.NewTimeInterval(start, end)
+	p.workingTime = ti
 	return ti, nil
 }
 
+// parseTime parses the time of the event on the current working day.
+func (p *FileParser) parseTime(s string) (time.Time, error) {
+	if p.workingTime == nil {
+		return time.Parse(p.cfg.TimeFormat, s)
+	}
+
+	return p.workingTime.AddDays(p.day).ParseTime(p.cfg.TimeFormat, s)
+}
+
 func (p *FileParser) readEvent() (*model.IncomingEvent, error) {
 	eventStrings := strings.SplitN(p.scanner.Text(), p.cfg.EventInfoSeparator, p.cfg.DistinctEventInfoCount)
 	if len(eventStrings) != p.cfg.DistinctEventInfoCount {
@@ -183,7 +742,7 @@ func (p *FileParser) readEvent() (*model.IncomingEvent, error) {
 	}
 	happensAtStr, eventTypeStr, clientDataStr := eventStrings[0], eventStrings[1], eventStrings[2]
 
-	happensAt, err := time.Parse(p.cfg.TimeFormat, happensAtStr)
+	happensAt, err := p.parseTime(happensAtStr)
 	if err != nil {
 		return nil, &apierror.ParseError{
 			RowNumber: p.rowNumber,
@@ -192,6 +751,13 @@ func (p *FileParser) readEvent() (*model.IncomingEvent, error) {
 		}
 	}
 
+	if p.lastEvent != nil && happensAt.Before(p.lastEvent.HappensAt) {
+		return nil, &apierror.ParseError{
+			RowNumber: p.rowNumber,
+			UserMsg:   apierror.ErrEventTimeBeforePrevious,
+		}
+	}
+
 	eventType, err := p.parseIncomingEventType(eventTypeStr)
 	if err != nil {
 		return nil, err
@@ -206,6 +772,29 @@ func (p *FileParser) readEvent() (*model.IncomingEvent, error) {
 	return event, nil
 }
 
+// parseSlot parses the reserved time, the slot ending before the start lasts over midnight.
+func (p *FileParser) parseSlot(fromStr, toStr string) (*model.TimeInterval, error) {
+	from, err := p.parseTime(fromStr)
+	if err != nil {
+		return nil, &apierror.ParseError{
+			RowNumber: p.rowNumber,
+			UserMsg:   apierror.ErrFailedToParseReservationTime,
+			BaseErr:   err,
+		}
+	}
+
+	to, err := p.parseTime(toStr)
+	if err != nil {
+		return nil, &apierror.ParseError{
+			RowNumber: p.rowNumber,
+			UserMsg:   apierror.ErrFailedToParseReservationTime,
+			BaseErr:   err,
+		}
+	}
+
+	return model.NewTimeInterval(from, to), nil
+}
+
 func (p *FileParser) parseIncomingEventType(s string) (model.IncomingEventType, error) {
 	eventType, err := strconv.Atoi(s)
 	if err != nil {
@@ -225,6 +814,8 @@ func (p *FileParser) parseIncomingEventType(s string) (model.IncomingEventType,
 		return model.Waits, nil
 	case int(model.Leaves):
 		return model.Leaves, nil
+	case int(model.Reserves):
+		return model.Reserves, nil
 	}
 
 	return 0, &apierror.ValidationError{
@@ -247,27 +838,28 @@ func (p *FileParser) parseClientData(eventType model.IncomingEventType, s string
 		}
 	}
 
-	var clientData model.ClientData
-	name := content[0]
-	switch eventType {
-	case model.Arrives:
-		clientData = model.NewClientArrives(name)
-	case model.Sits:
-		table, err := strconv.Atoi(content[1])
-		if err != nil {
+	var table int
+	if eventType == model.Sits || eventType == model.Reserves {
+		var err error
+		if table, err = strconv.Atoi(content[1]); err != nil {
 			return nil, &apierror.ParseError{
 				RowNumber: p.rowNumber,
 				UserMsg:   apierror.ErrFailedToParseClientTableNumber,
 				BaseErr:   err,
 			}
 		}
+	}
 
-		clientData = model.NewClientSits(name, table, p.maxTables)
-	case model.Waits:
-		clientData = model.NewClientWaits(name)
-	case model.Leaves:
-		clientData = model.NewClientLeaves(name)
-	default:
+	var slot *model.TimeInterval
+	if eventType == model.Reserves {
+		var err error
+		if slot, err = p.parseSlot(content[2], content[3]); err != nil {
+			return nil, err
+		}
+	}
+
+	clientData, ok := model.NewClientData(eventType, content[0], table, p.maxTables, slot)
+	if !ok {
 		return nil, &apierror.ValidationError{
 			RowNumber: p.rowNumber,
 			UserMsg:   apierror.ErrUnknownEventType,
@@ -69,6 +69,8 @@ func (s *parserSuite) compareCoreData(c1, c2 *model.CoreData) {
 	s.Equal(c1.TablesCount, c2.TablesCount)
 	s.Equal(c1.PricePerHour, c2.PricePerHour)
 	s.compareTimeIntervals(c1.WorkingTime, c2.WorkingTime)
+	s.Equal(c1.Categories, c2.Categories)
+	s.Equal(c1.Members, c2.Members)
 }
 
 func (s *parserSuite) compareEvent(e1, e2 *model.IncomingEvent) {
@@ -279,6 +281,208 @@ func (s *parserSuite) TestParser_ReadCoreData() {
 			input:  "10\n10:00 20:00\n10.5",
 			expErr: &apierror.ParseError{RowNumber: 3, UserMsg: apierror.ErrPricePerHourInvalidFormat},
 		},
+		{
+			name:  "table categories",
+			input: "10\n10:00 20:00\n10\ncategory vip 30 1-2,5\ncategory console 15 3\n10:00 1 client1",
+			exp: &model.CoreData{
+				TablesCount: 10,
+				WorkingTime: model.NewTimeInterval(
+					time.Date(0, 0, 0, 10, 0, 0, 0, time.UTC),
+					time.Date(0, 0, 0, 20, 0, 0, 0, time.UTC),
+				),
+				PricePerHour: 10,
+				Categories: []*model.TableCategory{
+					model.NewTableCategory("vip", 30, []int{1, 2, 5}),
+					model.NewTableCategory("console", 15, []int{3}),
+				},
+			},
+		},
+		{
+			name:   "table category without tables",
+			input:  "10\n10:00 20:00\n10\ncategory vip 30",
+			expErr: &apierror.ParseError{RowNumber: 4, UserMsg: apierror.ErrTableCategoryInvalidFormat},
+		},
+		{
+			name:   "table category invalid name",
+			input:  "10\n10:00 20:00\n10\ncategory 1vip 30 1",
+			expErr: &apierror.ValidationError{RowNumber: 4, UserMsg: apierror.ErrTableCategoryInvalidName},
+		},
+		{
+			name:   "table category duplicated",
+			input:  "10\n10:00 20:00\n10\ncategory vip 30 1\ncategory vip 20 2",
+			expErr: &apierror.ValidationError{RowNumber: 5, UserMsg: apierror.ErrTableCategoryDuplicated},
+		},
+		{
+			name:   "table category invalid price",
+			input:  "10\n10:00 20:00\n10\ncategory vip ab 1",
+			expErr: &apierror.ParseError{RowNumber: 4, UserMsg: apierror.ErrFailedToParseCategoryPrice},
+		},
+		{
+			name:   "table category zero price",
+			input:  "10\n10:00 20:00\n10\ncategory vip 0 1",
+			expErr: &apierror.ValidationError{RowNumber: 4, UserMsg: apierror.ErrValueMustBeMoreThanZero},
+		},
+		{
+			name:   "table category invalid range",
+			input:  "10\n10:00 20:00\n10\ncategory vip 30 3-1",
+			expErr: &apierror.ParseError{RowNumber: 4, UserMsg: apierror.ErrFailedToParseCategoryTables},
+		},
+		{
+			name:   "table category unknown table",
+			input:  "10\n10:00 20:00\n10\ncategory vip 30 9-11",
+			expErr: &apierror.ValidationError{RowNumber: 4, UserMsg: apierror.ErrValueTooBig},
+		},
+		{
+			name:  "tariffs",
+			input: "10\n10:00 02:00\n10\ncategory vip 30 1\ntariff 18:00 22:00 20\ntariff 22:00 02:00 50 vip",
+			exp: &model.CoreData{
+				TablesCount: 10,
+				WorkingTime: model.NewTimeInterval(
+					time.Date(0, 0, 0, 10, 0, 0, 0, time.UTC),
+					time.Date(0, 0, 1, 2, 0, 0, 0, time.UTC),
+				),
+				PricePerHour: 10,
+				Categories: []*model.TableCategory{
+					model.NewTableCategory("vip", 30, []int{1}),
+				},
+				Tariffs: []*model.Tariff{
+					model.NewTariff(
+						time.Date(0, 1, 1, 18, 0, 0, 0, time.UTC),
+						time.Date(0, 1, 1, 22, 0, 0, 0, time.UTC),
+						20, "",
+					),
+					model.NewTariff(
+						time.Date(0, 1, 1, 22, 0, 0, 0, time.UTC),
+						time.Date(0, 1, 1, 2, 0, 0, 0, time.UTC),
+						50, "vip",
+					),
+				},
+			},
+		},
+		{
+			name:   "tariffs overlap",
+			input:  "10\n10:00 02:00\n10\ncategory vip 30 1\ntariff 22:00 02:00 50 vip\ntariff 18:00 22:00 20\ntariff 01:00 03:00 40 vip",
+			expErr: &apierror.ValidationError{RowNumber: 7, UserMsg: apierror.ErrTariffOverlaps},
+		},
+		{
+			name:   "tariff invalid format",
+			input:  "10\n10:00 20:00\n10\ntariff 18:00 22:00",
+			expErr: &apierror.ParseError{RowNumber: 4, UserMsg: apierror.ErrTariffInvalidFormat},
+		},
+		{
+			name:   "tariff invalid time",
+			input:  "10\n10:00 20:00\n10\ntariff 18:00 2a:00 20",
+			expErr: &apierror.ParseError{RowNumber: 4, UserMsg: apierror.ErrFailedToParseTariffTime},
+		},
+		{
+			name:   "tariff empty interval",
+			input:  "10\n10:00 20:00\n10\ntariff 18:00 18:00 20",
+			expErr: &apierror.ValidationError{RowNumber: 4, UserMsg: apierror.ErrTariffEmptyInterval},
+		},
+		{
+			name:   "tariff invalid price",
+			input:  "10\n10:00 20:00\n10\ntariff 18:00 22:00 -5",
+			expErr: &apierror.ValidationError{RowNumber: 4, UserMsg: apierror.ErrValueMustBeMoreThanZero},
+		},
+		{
+			name:   "tariff unknown category",
+			input:  "10\n10:00 20:00\n10\ntariff 18:00 22:00 20 vip",
+			expErr: &apierror.ValidationError{RowNumber: 4, UserMsg: apierror.ErrTariffUnknownCategory},
+		},
+		{
+			name:  "members",
+			input: "10\n10:00 20:00\n10\nmember client1 client2\nmember client3",
+			exp: &model.CoreData{
+				TablesCount: 10,
+				WorkingTime: model.NewTimeInterval(
+					time.Date(0, 0, 0, 10, 0, 0, 0, time.UTC),
+					time.Date(0, 0, 0, 20, 0, 0, 0, time.UTC),
+				),
+				PricePerHour: 10,
+				Members:      []string{"client1", "client2", "client3"},
+			},
+		},
+		{
+			name:   "member without names",
+			input:  "10\n10:00 20:00\n10\nmember",
+			expErr: &apierror.ParseError{RowNumber: 4, UserMsg: apierror.ErrMemberInvalidFormat},
+		},
+		{
+			name:   "member invalid name",
+			input:  "10\n10:00 20:00\n10\nmember Client1",
+			expErr: &apierror.ValidationError{RowNumber: 4, UserMsg: apierror.ErrClientDataInvalidName},
+		},
+		{
+			name:   "member duplicated",
+			input:  "10\n10:00 20:00\n10\nmember client1\nmember client2 client1",
+			expErr: &apierror.ValidationError{RowNumber: 5, UserMsg: apierror.ErrMemberDuplicated},
+		},
+		{
+			name:  "balances",
+			input: "10\n10:00 20:00\n10\nbalance client1 100\nbalance client2 50",
+			exp: &model.CoreData{
+				TablesCount: 10,
+				WorkingTime: model.NewTimeInterval(
+					time.Date(0, 0, 0, 10, 0, 0, 0, time.UTC),
+					time.Date(0, 0, 0, 20, 0, 0, 0, time.UTC),
+				),
+				PricePerHour: 10,
+				Accounts: []*model.ClientAccount{
+					model.NewClientAccount("client1", 100),
+					model.NewClientAccount("client2", 50),
+				},
+			},
+		},
+		{
+			name:   "balance without amount",
+			input:  "10\n10:00 20:00\n10\nbalance client1",
+			expErr: &apierror.ParseError{RowNumber: 4, UserMsg: apierror.ErrBalanceInvalidFormat},
+		},
+		{
+			name:   "balance invalid name",
+			input:  "10\n10:00 20:00\n10\nbalance Client1 100",
+			expErr: &apierror.ValidationError{RowNumber: 4, UserMsg: apierror.ErrClientDataInvalidName},
+		},
+		{
+			name:   "balance invalid amount",
+			input:  "10\n10:00 20:00\n10\nbalance client1 ab",
+			expErr: &apierror.ParseError{RowNumber: 4, UserMsg: apierror.ErrFailedToParseBalance},
+		},
+		{
+			name:   "balance zero amount",
+			input:  "10\n10:00 20:00\n10\nbalance client1 0",
+			expErr: &apierror.ValidationError{RowNumber: 4, UserMsg: apierror.ErrValueMustBeMoreThanZero},
+		},
+		{
+			name:   "balance duplicated",
+			input:  "10\n10:00 20:00\n10\nbalance client1 100\nbalance client1 50",
+			expErr: &apierror.ValidationError{RowNumber: 5, UserMsg: apierror.ErrBalanceDuplicated},
+		},
+		{
+			name:   "date without value",
+			input:  "10\n10:00 20:00\n10\ndate",
+			expErr: &apierror.ParseError{RowNumber: 4, UserMsg: apierror.ErrDateInvalidFormat},
+		},
+		{
+			name:   "date invalid value",
+			input:  "10\n10:00 20:00\n10\ndate 15.03.2023",
+			expErr: &apierror.ParseError{RowNumber: 4, UserMsg: apierror.ErrFailedToParseDate},
+		},
+		{
+			name:   "date unknown timezone",
+			input:  "10\n10:00 20:00\n10\ndate 2023-03-15 Mars/Olympus",
+			expErr: &apierror.ValidationError{RowNumber: 4, UserMsg: apierror.ErrUnknownTimezone},
+		},
+		{
+			name:   "date duplicated",
+			input:  "10\n10:00 20:00\n10\ndate 2023-03-15\ndate 2023-03-16",
+			expErr: &apierror.ValidationError{RowNumber: 5, UserMsg: apierror.ErrDateDuplicated},
+		},
+		{
+			name:   "table in two categories",
+			input:  "10\n10:00 20:00\n10\ncategory vip 30 1-3\ncategory console 15 3",
+			expErr: &apierror.ValidationError{RowNumber: 5, UserMsg: apierror.ErrTableAlreadyCategorized},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -337,7 +541,7 @@ func (s *parserSuite) TestParser_ReadEvent() {
 		},
 		{
 			name:   "invalid event type",
-			input:  "10:00 5 client1",
+			input:  "10:00 6 client1",
 			expErr: &apierror.ValidationError{RowNumber: 1, UserMsg: apierror.ErrUnknownEventType},
 		},
 		{
@@ -381,3 +585,226 @@ func (s *parserSuite) TestParser_ReadEvent() {
 		})
 	}
 }
+
+func (s *parserSuite) TestParser_ReadEvents() {
+	testCases := []struct {
+		name            string
+		input           string
+		lenient         bool
+		windowSize      int
+		expTimes        []string
+		expErr          error
+		expEventsBefore int
+	}{
+		{
+			name:     "chronological events",
+			input:    "10:00 1 client1\n10:00 1 client2\n11:00 4 client1",
+			expTimes: []string{"10:00", "10:00", "11:00"},
+		},
+		{
+			name:            "time goes back",
+			input:           "10:00 1 client1\n11:00 1 client2\n10:30 4 client1",
+			expErr:          &apierror.ParseError{RowNumber: 3, UserMsg: apierror.ErrEventTimeBeforePrevious},
+			expEventsBefore: 2,
+		},
+		{
+			name:       "lenient, late event inside window",
+			input:      "10:00 1 client1\n11:00 1 client2\n10:30 4 client1\n12:00 4 client2",
+			lenient:    true,
+			windowSize: 2,
+			expTimes:   []string{"10:00", "10:30", "11:00", "12:00"},
+		},
+		{
+			name:       "lenient, same time keeps input order",
+			input:      "11:00 1 client2\n10:00 1 client1\n11:00 4 client2",
+			lenient:    true,
+			windowSize: 2,
+			expTimes:   []string{"10:00", "11:00", "11:00"},
+		},
+		{
+			name:            "lenient, late event outside window",
+			input:           "10:00 1 client1\n11:00 1 client2\n12:00 1 client3\n10:30 4 client1",
+			lenient:         true,
+			windowSize:      1,
+			expErr:          &apierror.ParseError{RowNumber: 4, UserMsg: apierror.ErrEventTimeBeforePrevious},
+			expEventsBefore: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			cfg := *s.cfg
+			cfg.LenientOrdering = tc.lenient
+			cfg.ReorderWindowSize = tc.windowSize
+
+			p := NewFileParser(scannerFromStr(tc.input), &cfg)
+
+			var (
+				times []string
+				err   error
+			)
+
+			for wrapped := range p.ReadEvents(3) {
+				if wrapped.Err != nil {
+					err = wrapped.Err
+					continue
+				}
+
+				times = append(times, wrapped.Event.HappensAt.Format(cfg.TimeFormat))
+			}
+
+			s.compareErrors(tc.expErr, err)
+			if tc.expErr != nil {
+				s.Len(times, tc.expEventsBefore)
+				return
+			}
+
+			s.Equal(tc.expTimes, times)
+		})
+	}
+}
+
+func (s *parserSuite) TestParser_ReadEventsAfterDirectives() {
+	p := NewFileParser(scannerFromStr("10\n10:00 20:00\n10\ncategory vip 30 1\n10:00 7 client1"), s.cfg)
+
+	coreData, err := p.ReadCoreData()
+	s.NoError(err)
+
+	wrapped := <-p.ReadEvents(coreData.TablesCount)
+	s.compareErrors(&apierror.ValidationError{RowNumber: 5, UserMsg: apierror.ErrUnknownEventType}, wrapped.Err)
+}
+
+func (s *parserSuite) TestParser_ReadEventsOverDays() {
+	testCases := []struct {
+		name     string
+		input    string
+		expTimes []string
+		expDays  []int
+		expErr   error
+	}{
+		{
+			name:     "overnight working time",
+			input:    "3\n20:00 04:00\n10\n19:50 1 client1\n21:00 1 client2\n00:00 2 client2 1\n04:00 4 client2",
+			expTimes: []string{"Jan 1 19:50", "Jan 1 21:00", "Jan 2 00:00", "Jan 2 04:00"},
+			expDays:  []int{0, 0, 0, 0},
+		},
+		{
+			name:     "consecutive days",
+			input:    "3\n20:00 04:00\n10\n21:00 1 client1\n01:30 4 client1\nday\n22:00 1 client2\n03:00 4 client2\nday\nday\n20:30 1 client3",
+			expTimes: []string{"Jan 1 21:00", "Jan 2 01:30", "Jan 2 22:00", "Jan 3 03:00", "Jan 4 20:30"},
+			expDays:  []int{0, 0, 1, 1, 3},
+		},
+		{
+			name:   "event after the overnight closing",
+			input:  "3\n20:00 04:00\n10\n21:00 1 client1\n01:30 4 client1\n05:00 1 client2",
+			expErr: &apierror.ParseError{RowNumber: 6, UserMsg: apierror.ErrEventTimeBeforePrevious},
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			p := NewFileParser(scannerFromStr(tc.input), s.cfg)
+
+			coreData, err := p.ReadCoreData()
+			s.Require().NoError(err)
+
+			var (
+				times []string
+				days  []int
+			)
+
+			err = nil
+			for wrapped := range p.ReadEvents(coreData.TablesCount) {
+				if wrapped.Err != nil {
+					err = wrapped.Err
+					continue
+				}
+
+				times = append(times, wrapped.Event.HappensAt.Format("Jan 2 15:04"))
+				days = append(days, wrapped.Day)
+			}
+
+			s.compareErrors(tc.expErr, err)
+			if tc.expErr != nil {
+				return
+			}
+
+			s.Equal(tc.expTimes, times)
+			s.Equal(tc.expDays, days)
+		})
+	}
+}
+
+func (s *parserSuite) TestParser_ReadEventsWithDate() {
+	input := "3\n20:00 04:00\n10\ndate 2023-03-25 Europe/Berlin\n" +
+		"21:00 1 client1\n01:30 4 client1\n2023-03-26T03:00:00+02:00 1 client2\n" +
+		"day\n20:30 1 client3\n2023-03-26T23:00:00Z 4 client3\n"
+
+	p := NewFileParser(scannerFromStr(input), s.cfg)
+
+	coreData, err := p.ReadCoreData()
+	s.Require().NoError(err)
+	s.Equal("2023-03-25T20:00:00+01:00", coreData.WorkingTime.Start.Format(time.RFC3339))
+
+	// the clocks go forward at 02:00 of March 26, so the club closes in seven hours
+	s.Equal("2023-03-26T04:00:00+02:00", coreData.WorkingTime.End.Format(time.RFC3339))
+
+	var times []string
+	for wrapped := range p.ReadEvents(coreData.TablesCount) {
+		s.Require().NoError(wrapped.Err)
+		times = append(times, wrapped.Event.HappensAt.Format(time.RFC3339))
+	}
+
+	s.Equal([]string{
+		"2023-03-25T21:00:00+01:00",
+		"2023-03-26T01:30:00+01:00",
+		"2023-03-26T03:00:00+02:00",
+		"2023-03-26T20:30:00+02:00",
+		"2023-03-27T01:00:00+02:00",
+	}, times)
+}
+
+func (s *parserSuite) TestParser_Validate() {
+	testCases := []struct {
+		name    string
+		input   string
+		expErrs []error
+	}{
+		{
+			name:  "valid input",
+			input: "3\n09:00 19:00\n10\n09:41 1 client1\n09:54 2 client1 1",
+		},
+		{
+			name:  "errors in header and events",
+			input: "ab\n09:00 19:00\n-10\ncategory vip 30 1\ntariff 18:00 18:00 20\n09:41 1 client1\n09:54 7 client1\n09:30 1 client2\n10:00 2 client2 100\n10:01 4 client2",
+			expErrs: []error{
+				&apierror.ParseError{RowNumber: 1, UserMsg: apierror.ErrTablesCountInvalidFormat},
+				&apierror.ValidationError{RowNumber: 3, UserMsg: apierror.ErrValueMustBeMoreThanZero},
+				&apierror.ValidationError{RowNumber: 5, UserMsg: apierror.ErrTariffEmptyInterval},
+				&apierror.ValidationError{RowNumber: 7, UserMsg: apierror.ErrUnknownEventType},
+				&apierror.ParseError{RowNumber: 8, UserMsg: apierror.ErrEventTimeBeforePrevious},
+			},
+		},
+		{
+			name:  "table number is checked by the valid tables count",
+			input: "3\n09:00 19:00\n10\n09:54 2 client1 4",
+			expErrs: []error{
+				&apierror.ValidationError{RowNumber: 4, UserMsg: apierror.ErrValueTooBig},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			p := NewFileParser(scannerFromStr(tc.input), s.cfg)
+
+			errs := p.Validate()
+			s.Len(errs, len(tc.expErrs))
+			for i := range tc.expErrs {
+				if i < len(errs) {
+					s.compareErrors(tc.expErrs[i], errs[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package parser
+
+import (
+	"container/heap"
+	"yadro-intern/internal/model"
+)
+
+type reorderItem struct {
+	event     *model.IncomingEvent
+	rowNumber int
+	day       int
+}
+
+// reorderWindow keeps the latest read events sorted by time,
+// events with the same time keep the order they were read in.
+type reorderWindow []reorderItem
+
+func (w reorderWindow) Len() int { return len(w) }
+
+func (w reorderWindow) Less(i, j int) bool {
+	if w[i].event.HappensAt.Equal(w[j].event.HappensAt) {
+		return w[i].rowNumber < w[j].rowNumber
+	}
+
+	return w[i].event.HappensAt.Before(w[j].event.HappensAt)
+}
+
+func (w reorderWindow) Swap(i, j int) { w[i], w[j] = w[j], w[i] }
+
+func (w *reorderWindow) Push(x any) { *w = append(*w, x.(reorderItem)) }
+
+func (w *reorderWindow) Pop() any {
+	old := *w
+	item := old[len(old)-1]
+	*w = old[:len(old)-1]
+	return item
+}
+
+func (w *reorderWindow) push(event *model.IncomingEvent, rowNumber, day int) {
+	heap.Push(w, reorderItem{event: event, rowNumber: rowNumber, day: day})
+}
+
+// pop returns the earliest event in the window and the number of its day.
+func (w *reorderWindow) pop() (*model.IncomingEvent, int) {
+	item := heap.Pop(w).(reorderItem)
+	return item.event, item.day
+}
@@ -0,0 +1,101 @@
+package processor
+
+import (
+	"sort"
+	"time"
+	"yadro-intern/internal/model"
+)
+
+// newBalances returns the prepaid balances of the clients at the opening.
+func newBalances(coreData *model.CoreData) map[string]int {
+	balances := make(map[string]int, len(coreData.Accounts))
+	for _, account := range coreData.Accounts {
+		balances[account.Name] = account.Balance
+	}
+
+	return balances
+}
+
+// Balances returns the current balances of the clients with the accounts ordered by the name.
+//
+// Balances are kept between the working days, sessions in progress aren't deducted yet.
+func (p *EventProcessorImpl) Balances() []BalanceState {
+	balances := make([]BalanceState, 0, len(p.balances))
+	for client, balance := range p.balances {
+		balances = append(balances, BalanceState{Client: client, Balance: balance})
+	}
+
+	sort.Slice(balances, func(i, j int) bool {
+		return balances[i].Client < balances[j].Client
+	})
+
+	return balances
+}
+
+// deduct charges the session amount from the balance of the client, if the client has the account.
+func (p *EventProcessorImpl) deduct(client string, amount int) {
+	if balance, ok := p.balances[client]; ok {
+		p.balances[client] = balance - amount
+	}
+}
+
+// nextExhausted returns the seated client, whose balance is exhausted first not later than at,
+// and the moment the client must leave.
+func (p *EventProcessorImpl) nextExhausted(at time.Time) (string, time.Time, bool) {
+	var (
+		client    string
+		exhausted time.Time
+		found     bool
+	)
+
+	for _, pair := range p.tables.GetAll() {
+		name := pair.Value.Client.GetName()
+		balance, ok := p.balances[name]
+		if !ok {
+			continue
+		}
+
+		leaveAt, ok := p.exhaustionTime(pair.Key, pair.Value.HappensAt, balance)
+		if !ok || leaveAt.After(at) {
+			continue
+		}
+
+		if !found || leaveAt.Before(exhausted) || leaveAt.Equal(exhausted) && name < client {
+			client, exhausted, found = name, leaveAt, true
+		}
+	}
+
+	return client, exhausted, found
+}
+
+// exhaustionTime returns the last moment the balance covers the session at the table started at from,
+// false if the balance covers the session till the closing time.
+//
+// The moment is found in whole seconds, because the charge only grows with the usage time.
+func (p *EventProcessorImpl) exhaustionTime(table int, from time.Time, balance int) (time.Time, bool) {
+	rate := p.rateOf(table, from)
+	limit := p.workingTime().End.Sub(from)
+	if p.billing.Charge(limit, rate) <= balance {
+		return time.Time{}, false
+	}
+
+	lo, hi := time.Duration(0), limit/time.Second
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if p.billing.Charge(mid*time.Second, rate) <= balance {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+
+	return from.Add(lo * time.Second), true
+}
+
+// forceLeave makes the client leave, because the balance is exhausted,
+// the freed table is taken by the next waiting client.
+func (p *EventProcessorImpl) forceLeave(client string, at time.Time) {
+	leaves := model.NewClientLeaves(client)
+	p.writeOutEvent(model.NewClientOutOfBalanceEvent(at, leaves))
+	p.processLeaves(model.NewIncomingEvent(at, model.Leaves, leaves), false)
+}
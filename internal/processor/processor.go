@@ -2,15 +2,15 @@ package processor
 
 import (
 	"errors"
-	"fmt"
 	"io"
-	"math"
 	"sort"
 	"time"
 	"yadro-intern/cmd/config"
 	"yadro-intern/internal/apierror"
+	"yadro-intern/internal/billing"
 	"yadro-intern/internal/ifces"
 	"yadro-intern/internal/model"
+	"yadro-intern/internal/output"
 	"yadro-intern/internal/storage"
 )
 
@@ -22,9 +22,13 @@ type EventProcessor interface {
 	// It returns an error if an error occurs while processing events.
 	ProcessEvents(<-chan model.WrappedIncomingEvent) error
 
-	// ShowRevenue displays the final result of the program.
+	// ShowRevenue displays the final result of the program:
+	// revenue of every table, then revenue of every table category.
 	//
-	// Used, when all events are processed.
+	// Results kept by the output format are written here.
+	//
+	// Used, when all events are processed,
+	// revenue of the previous days is shown by ProcessEvents.
 	ShowRevenue()
 }
 
@@ -33,6 +37,12 @@ type EventProcessorImpl struct {
 	cfg      *config.Processor
 	coreData *model.CoreData
 
+	// format writes the processing results to out in the configured format.
+	format output.Formatter
+
+	// billing decides, how much a client pays for the time spent at the table.
+	billing billing.Policy
+
 	// tables is mapper from table number to sit event.
 	// we need to know, when and who sat at the table.
 	tables storage.Storage[int, *model.IncomingEvent]
@@ -49,12 +59,45 @@ type EventProcessorImpl struct {
 	revenue storage.Storage[int, *model.RevenueStats]
 
 	waitingQueue storage.Queue[model.ClientData]
+
+	// reservations are the tables booked for the time slots, sorted by the slot start.
+	// a reservation is removed, when the client sits at the table or doesn't come in time.
+	reservations []*model.ClientReserves
+
+	// sessions are the finished sessions at the tables in the order they ended.
+	// table switches and seatings from the queue start new sessions.
+	sessions []*model.Session
+
+	// balances are the prepaid balances of the clients with the accounts,
+	// they are kept between the working days.
+	balances map[string]int
+
+	// days are the results of the finished working days, the current day isn't included.
+	days []*DayResult
+
+	// listeners are notified about every written event.
+	listeners []Listener
+
+	// day is the number of the current working day, 0 for the first day.
+	day int
 }
 
+// DayResult is the revenue and the finished sessions of the working day.
+type DayResult struct {
+	WorkingTime *model.TimeInterval
+	Revenue     storage.Storage[int, *model.RevenueStats]
+	Sessions    []*model.Session
+}
+
+// Listener receives every incoming and outgoing event written by the processor,
+// it's called synchronously, so it must not block.
+type Listener func(event ifces.TimeFormatter)
+
 func NewEventProcessor(
 	out io.Writer,
 	cfg *config.Processor,
 	coreData *model.CoreData,
+	billingPolicy billing.Policy,
 	tablesStorage storage.Storage[int, *model.IncomingEvent],
 	revenueStorage storage.Storage[int, *model.RevenueStats],
 	clientsStorage storage.Storage[string, int],
@@ -62,31 +105,133 @@ func NewEventProcessor(
 ) *EventProcessorImpl {
 	return &EventProcessorImpl{
 		out:          out,
+		format:       output.NewFormatter(cfg.OutputFormat, out, cfg.TimeFormat),
 		coreData:     coreData,
 		cfg:          cfg,
+		billing:      billingPolicy,
 		tables:       tablesStorage,
 		clients:      clientsStorage,
 		revenue:      revenueStorage,
 		waitingQueue: clientsQueue,
+		balances:     newBalances(coreData),
 	}
 }
 
 func (p *EventProcessorImpl) ProcessEvents(events <-chan model.WrappedIncomingEvent) error {
-	_, _ = io.WriteString(p.out, p.coreData.WorkingTime.Start.Format(p.cfg.TimeFormat)+"\n")
+	p.Start()
 
 	for wrapped := range events {
 		if wrapped.Err != nil {
 			return wrapped.Err
 		}
 
-		p.writeOutEvent(wrapped.Event)
-		p.processEvent(wrapped.Event)
+		for p.day < wrapped.Day {
+			p.nextDay()
+		}
+
+		p.Apply(wrapped.Event)
 	}
 
+	p.Finish()
+	return nil
+}
+
+// Start writes the opening of the working day.
+//
+// Start, Apply and Finish are used instead of ProcessEvents,
+// when events aren't known in advance, for example, in the live session.
+func (p *EventProcessorImpl) Start() {
+	p.format.WriteStart(p.workingTime().Start)
+}
+
+// Apply writes the incoming event and processes it.
+//
+// Events must be applied in chronological order.
+func (p *EventProcessorImpl) Apply(event *model.IncomingEvent) {
+	p.advance(event.HappensAt)
+
+	p.writeOutEvent(event)
+	p.processEvent(event)
+}
+
+// Finish makes all remaining clients leave and writes the closing of the working day.
+func (p *EventProcessorImpl) Finish() {
+	p.advance(p.workingTime().End)
+	p.reservations = nil
 	p.leaveClients()
 
-	_, _ = io.WriteString(p.out, p.coreData.WorkingTime.End.Format(p.cfg.TimeFormat)+"\n")
-	return nil
+	p.format.WriteEnd(p.workingTime().End)
+}
+
+// advance processes what happens with the time passing till at in chronological order:
+// clients with exhausted balances leave, reserved tables are freed at the start of the slots
+// and reservations of the clients, who haven't come, expire.
+//
+// At the same moment a client leaves first, so the freed table can be taken by the waiting client.
+func (p *EventProcessorImpl) advance(at time.Time) {
+	for {
+		client, exhausted, ok := p.nextExhausted(at)
+		started := p.nextStarted(at)
+		expired := p.nextExpired(at)
+
+		switch {
+		case ok && (started == nil || !started.GetSlot().Start.Before(exhausted)) &&
+			(expired == nil || !p.releaseTime(expired).Before(exhausted)):
+			p.forceLeave(client, exhausted)
+		case started != nil && (expired == nil || !p.releaseTime(expired).Before(started.GetSlot().Start)):
+			p.start(started)
+		case expired != nil:
+			p.expire(expired)
+		default:
+			return
+		}
+	}
+}
+
+// nextDay finishes the current working day with its revenue and starts the next one,
+// the revenue of the next day is counted from scratch, the results of the day are kept.
+func (p *EventProcessorImpl) nextDay() {
+	p.Finish()
+	p.ShowRevenue()
+
+	revenue := storage.NewInMemoryStorage[int, *model.RevenueStats]()
+	for _, pair := range p.revenue.GetAll() {
+		revenue.Set(pair.Key, pair.Value)
+		p.revenue.Delete(pair.Key)
+	}
+
+	p.days = append(p.days, &DayResult{WorkingTime: p.workingTime(), Revenue: revenue, Sessions: p.sessions})
+
+	p.sessions = nil
+	p.day++
+	p.Start()
+}
+
+// workingTime returns the working time of the current day.
+func (p *EventProcessorImpl) workingTime() *model.TimeInterval {
+	if p.day == 0 {
+		return p.coreData.WorkingTime
+	}
+
+	return p.coreData.WorkingTime.AddDays(p.day)
+}
+
+// AddListener subscribes the listener to the events written after the call.
+func (p *EventProcessorImpl) AddListener(listener Listener) {
+	p.listeners = append(p.listeners, listener)
+}
+
+// Sessions returns the finished sessions of the current working day in the order they ended,
+// they must not be changed.
+func (p *EventProcessorImpl) Sessions() []*model.Session {
+	return p.sessions
+}
+
+// Days returns the results of the finished working days and of the current one in the order of the days,
+// they must not be changed.
+func (p *EventProcessorImpl) Days() []*DayResult {
+	current := &DayResult{WorkingTime: p.workingTime(), Revenue: p.revenue, Sessions: p.sessions}
+	return append(p.days[:len(p.days):len(p.days)], current)
 }
 
 func (p *EventProcessorImpl) ShowRevenue() {
@@ -96,8 +241,35 @@ func (p *EventProcessorImpl) ShowRevenue() {
 			continue
 		}
 
-		_, _ = fmt.Fprintf(p.out, "%d %s\n", i, stats)
+		var categoryName string
+		if category := p.coreData.CategoryOf(i); category != nil {
+			categoryName = category.Name
+		}
+
+		p.format.WriteTableRevenue(i, categoryName, stats)
+	}
+
+	for _, category := range p.coreData.Categories {
+		p.format.WriteCategoryRevenue(category.Name, p.categoryRevenue(category))
+	}
+
+	_ = p.format.Flush()
+}
+
+// categoryRevenue sums up the revenue of all tables of the category.
+func (p *EventProcessorImpl) categoryRevenue(category *model.TableCategory) *model.RevenueStats {
+	total := &model.RevenueStats{}
+	for _, table := range category.Tables {
+		stats, ok := p.revenue.Get(table)
+		if !ok {
+			continue
+		}
+
+		total.Income += stats.Income
+		total.UsageTime += stats.UsageTime
 	}
+
+	return total
 }
 
 func (p *EventProcessorImpl) leaveClients() {
@@ -106,18 +278,24 @@ func (p *EventProcessorImpl) leaveClients() {
 		clients = append(clients, pair.Key)
 	}
 
+	// queue is drained before anyone leaves, otherwise clients
+	// leaving at the closing time will free tables for the waiting ones.
+	//
+	// waiting clients are usually in the club already, so they are counted once.
 	for p.waitingQueue.Len() > 0 {
 		client, _ := p.waitingQueue.Pop()
-		clients = append(clients, client.GetName())
+		if _, ok := p.clients.Get(client.GetName()); !ok {
+			clients = append(clients, client.GetName())
+		}
 	}
 
 	sort.Strings(clients)
 
 	for _, clientName := range clients {
-		leaveEvent := model.NewIncomingEvent(p.coreData.WorkingTime.End, model.Leaves, model.NewClientLeaves(clientName))
+		leaveEvent := model.NewIncomingEvent(p.workingTime().End, model.Leaves, model.NewClientLeaves(clientName))
 
 		if _, ok := p.clients.Get(clientName); !ok {
-			p.writeOutEvent(leaveEvent)
+			p.writeOutEvent(model.NewClientLeftEvent(leaveEvent.HappensAt, leaveEvent.Client))
 			continue
 		}
 
@@ -135,19 +313,26 @@ func (p *EventProcessorImpl) processEvent(event *model.IncomingEvent) {
 		p.processWaits(event)
 	case model.Leaves:
 		p.processLeaves(event, false)
+	case model.Reserves:
+		p.processReserves(event)
 	}
 }
 
 func (p *EventProcessorImpl) writeOutEvent(event ifces.TimeFormatter) {
-	if p.out == nil {
-		return
+	switch e := event.(type) {
+	case *model.IncomingEvent:
+		p.format.WriteIncoming(e)
+	case *model.OutgoingEvent:
+		p.format.WriteOutgoing(e)
 	}
 
-	_, _ = io.WriteString(p.out, event.String(p.cfg.TimeFormat)+"\n")
+	for _, listener := range p.listeners {
+		listener(event)
+	}
 }
 
 func (p *EventProcessorImpl) processArrives(event *model.IncomingEvent) {
-	if !p.coreData.WorkingTime.In(event.HappensAt) {
+	if !p.workingTime().In(event.HappensAt) {
 		notOpenYet := model.NewErrorEvent(event.HappensAt, errors.New(apierror.ErrNotOpenYet))
 		p.writeOutEvent(notOpenYet)
 		return
@@ -170,12 +355,32 @@ func (p *EventProcessorImpl) processSits(event *model.IncomingEvent, generateSat
 	}
 
 	clientSits := event.Client.(*model.ClientSits)
-	if _, ok := p.tables.Get(clientSits.GetTable()); ok {
+	reservation := p.reservationOf(clientSits.GetTable(), event.HappensAt)
+	reserved := reservation != nil && reservation.GetName() == clientSits.GetName()
+
+	_, busy := p.tables.Get(clientSits.GetTable())
+	if busy && !reserved {
 		alreadyTaken := model.NewErrorEvent(event.HappensAt, errors.New(apierror.ErrTableIsBusy))
 		p.writeOutEvent(alreadyTaken)
 		return
 	}
 
+	if reservation != nil {
+		if !reserved {
+			reservedByOther := model.NewErrorEvent(event.HappensAt, errors.New(apierror.ErrTableIsReserved))
+			p.writeOutEvent(reservedByOther)
+			return
+		}
+
+		// the reserving client has come, so the client at the table gives it up
+		if busy {
+			p.waitingQueue.Remove(clientNamed(clientSits.GetName()))
+			p.evict(clientSits.GetTable(), event.HappensAt)
+		}
+
+		p.removeReservation(reservation)
+	}
+
 	prevSitTable, ok := p.clients.Get(event.Client.GetName())
 	if ok && prevSitTable != -1 {
 		p.updateRevenue(prevSitTable, event.HappensAt)
@@ -192,19 +397,38 @@ func (p *EventProcessorImpl) processSits(event *model.IncomingEvent, generateSat
 }
 
 func (p *EventProcessorImpl) processWaits(event *model.IncomingEvent) {
-	if p.tables.Len() < p.coreData.TablesCount {
+	// the client is in the queue already, so waiting again changes nothing
+	if p.waitingQueue.Contains(clientNamed(event.Client.GetName())) {
+		return
+	}
+
+	if p.hasFreeTable(event.Client.GetName(), event.HappensAt) {
 		haveFreeTables := model.NewErrorEvent(event.HappensAt, errors.New(apierror.ErrCantWaitLonger))
 		p.writeOutEvent(haveFreeTables)
 		return
 	}
 
-	if p.waitingQueue.Len() >= p.coreData.TablesCount {
+	// the reserved table is busy, the reserving client waits for it and the client at the table gives it up
+	if reservation := p.reservationFor(event.Client.GetName(), event.HappensAt); reservation != nil {
+		p.waitingQueue.Push(p.waiting(event.Client.GetName()))
+		p.evict(reservation.GetTable(), event.HappensAt)
+		return
+	}
+
+	if p.waitingQueue.Len() >= p.maxQueueLength() {
 		queueIsFull := model.NewClientLeftEvent(event.HappensAt, event.Client)
 		p.writeOutEvent(queueIsFull)
+
+		// the waiting client has left the club, so he isn't made to leave at the closing time again,
+		// the seated client keeps his table
+		if table, ok := p.clients.Get(event.Client.GetName()); ok && table == -1 {
+			p.processLeaves(model.NewIncomingEvent(event.HappensAt, model.Leaves, event.Client), false)
+		}
+
 		return
 	}
 
-	p.waitingQueue.Push(event.Client)
+	p.waitingQueue.Push(p.waiting(event.Client.GetName()))
 }
 
 func (p *EventProcessorImpl) processLeaves(event *model.IncomingEvent, generateLeftEvent bool) {
@@ -216,6 +440,7 @@ func (p *EventProcessorImpl) processLeaves(event *model.IncomingEvent, generateL
 	}
 
 	p.clients.Delete(event.Client.GetName())
+	p.waitingQueue.Remove(clientNamed(event.Client.GetName()))
 	if generateLeftEvent {
 		p.writeOutEvent(model.NewClientLeftEvent(event.HappensAt, event.Client))
 	}
@@ -226,14 +451,30 @@ func (p *EventProcessorImpl) processLeaves(event *model.IncomingEvent, generateL
 
 	p.updateRevenue(busyTable, event.HappensAt)
 	p.tables.Delete(busyTable)
+	p.seatWaiting(busyTable, event.HappensAt)
+}
 
-	if p.waitingQueue.Len() > 0 {
-		client, _ := p.waitingQueue.Pop()
-		sitClientData := model.NewClientSits(client.GetName(), busyTable, p.coreData.TablesCount)
-		sitEvent := model.NewIncomingEvent(event.HappensAt, model.Sits, sitClientData)
-		p.clients.Set(client.GetName(), -1)
-		p.processSits(sitEvent, true)
+// seatWaiting seats the next waiting client at the free table,
+// only the client, who reserved the table, can take it during the reservation.
+func (p *EventProcessorImpl) seatWaiting(table int, at time.Time) {
+	var client model.ClientData
+	if reservation := p.reservationOf(table, at); reservation != nil {
+		if !p.waitingQueue.Remove(clientNamed(reservation.GetName())) {
+			return
+		}
+
+		client = reservation
+	} else {
+		var err error
+		if client, err = p.waitingQueue.Pop(); err != nil {
+			return
+		}
 	}
+
+	sitClientData := model.NewClientSits(client.GetName(), table, p.coreData.TablesCount)
+	sitEvent := model.NewIncomingEvent(at, model.Sits, sitClientData)
+	p.clients.Set(client.GetName(), -1)
+	p.processSits(sitEvent, true)
 }
 
 func (p *EventProcessorImpl) updateRevenue(busyTable int, releaseTime time.Time) {
@@ -246,17 +487,54 @@ func (p *EventProcessorImpl) updateRevenue(busyTable int, releaseTime time.Time)
 		return
 	}
 
-	sittingTime := int(math.Ceil(releaseTime.Sub(sittingEvent.HappensAt).Hours()))
 	prevRevenue, ok := p.revenue.Get(busyTable)
 	if !ok {
 		prevRevenue = &model.RevenueStats{
-			Income:    0,
-			UsageTime: time.Duration(0),
+			Income:        0,
+			UsageTime:     time.Duration(0),
+			FirstOccupied: sittingEvent.HappensAt,
 		}
 	}
 
-	p.revenue.Set(busyTable, &model.RevenueStats{
-		Income:    prevRevenue.Income + p.coreData.PricePerHour*sittingTime,
-		UsageTime: prevRevenue.UsageTime + releaseTime.Sub(sittingEvent.HappensAt),
-	})
+	stats := p.withSession(*prevRevenue, busyTable, sittingEvent.HappensAt, releaseTime)
+	stats.LastReleased = releaseTime
+	p.revenue.Set(busyTable, &stats)
+	p.deduct(sittingEvent.Client.GetName(), stats.Income-prevRevenue.Income)
+
+	p.sessions = append(p.sessions, model.NewSession(
+		sittingEvent.Client.GetName(),
+		busyTable,
+		sittingEvent.HappensAt,
+		releaseTime,
+		p.billing.Billed(releaseTime.Sub(sittingEvent.HappensAt)),
+		stats.Income-prevRevenue.Income,
+	))
+}
+
+// withSession returns the stats of the table with one more session added.
+func (p *EventProcessorImpl) withSession(stats model.RevenueStats, table int, from, to time.Time) model.RevenueStats {
+	usageTime := to.Sub(from)
+
+	stats.Income += p.billing.Charge(usageTime, p.rateOf(table, from))
+	stats.UsageTime += usageTime
+	stats.Sessions++
+	return stats
+}
+
+// rateOf returns the rate of the table for the session started at the given time.
+func (p *EventProcessorImpl) rateOf(table int, sessionStart time.Time) billing.Rate {
+	tariffs := p.coreData.TariffsOf(table)
+	if len(tariffs) == 0 {
+		return billing.HourlyRate(p.coreData.PriceOf(table))
+	}
+
+	return billing.NewTariffRate(p.coreData.PriceOf(table), tariffs, sessionStart)
+}
+
+// clientNamed matches the client data by the client name,
+// because the same client is described by different events.
+func clientNamed(name string) func(model.ClientData) bool {
+	return func(client model.ClientData) bool {
+		return client.GetName() == name
+	}
 }
@@ -4,11 +4,14 @@ import (
 	"bytes"
 	"errors"
 	"github.com/stretchr/testify/suite"
+	"io"
 	"strings"
 	"testing"
 	"time"
 	"yadro-intern/cmd/config"
 	"yadro-intern/internal/apierror"
+	"yadro-intern/internal/billing"
+	"yadro-intern/internal/ifces"
 	"yadro-intern/internal/model"
 	"yadro-intern/internal/storage"
 )
@@ -43,6 +46,7 @@ func newProcessorWithCoreData(s *processorTestSuite, coreData *model.CoreData) *
 		&bytes.Buffer{},
 		s.cfg,
 		coreData,
+		billing.NewHourly(),
 		storage.NewInMemoryStorage[int, *model.IncomingEvent](),
 		storage.NewInMemoryStorage[int, *model.RevenueStats](),
 		storage.NewInMemoryStorage[string, int](),
@@ -298,8 +302,11 @@ func (s *processorTestSuite) TestProcessSits() {
 			},
 			buildRevenue: func(p *EventProcessorImpl) *model.RevenueStats {
 				return &model.RevenueStats{
-					Income:    p.coreData.PricePerHour * 3,
-					UsageTime: time.Duration(3) * time.Hour,
+					Income:        p.coreData.PricePerHour * 3,
+					UsageTime:     time.Duration(3) * time.Hour,
+					Sessions:      1,
+					FirstOccupied: time.Date(0, 0, 0, 9, 0, 0, 0, time.UTC),
+					LastReleased:  time.Date(0, 0, 0, 12, 0, 0, 0, time.UTC),
 				}
 			},
 			finalCheck: func(p *EventProcessorImpl) {
@@ -329,8 +336,11 @@ func (s *processorTestSuite) TestProcessSits() {
 			},
 			buildRevenue: func(p *EventProcessorImpl) *model.RevenueStats {
 				return &model.RevenueStats{
-					Income:    p.coreData.PricePerHour * 1,
-					UsageTime: time.Duration(30) * time.Minute,
+					Income:        p.coreData.PricePerHour * 1,
+					UsageTime:     time.Duration(30) * time.Minute,
+					Sessions:      1,
+					FirstOccupied: time.Date(0, 0, 0, 11, 30, 0, 0, time.UTC),
+					LastReleased:  time.Date(0, 0, 0, 12, 0, 0, 0, time.UTC),
 				}
 			},
 			finalCheck: func(p *EventProcessorImpl) {
@@ -426,7 +436,7 @@ func (s *processorTestSuite) TestProcessWaits() {
 					model.NewClientSits("client2", 1, p.coreData.TablesCount),
 				))
 
-				p.waitingQueue.Push(model.NewClientWaits("client1"))
+				p.waitingQueue.Push(model.NewClientWaits("client3"))
 			},
 		},
 		{
@@ -456,6 +466,37 @@ func (s *processorTestSuite) TestProcessWaits() {
 				s.Equal(model.NewClientWaits("client1"), top)
 			},
 		},
+		{
+			name: "already waiting",
+			buildEvent: func(p *EventProcessorImpl) *model.IncomingEvent {
+				return model.NewIncomingEvent(
+					time.Date(0, 0, 0, 12, 0, 0, 0, time.UTC),
+					model.Waits,
+					model.NewClientWaits("client1"),
+				)
+			},
+			buildExpected: func(p *EventProcessorImpl) string {
+				return ""
+			},
+			prep: func(p *EventProcessorImpl) {
+				p.coreData.TablesCount = 2
+				p.tables.Set(1, model.NewIncomingEvent(
+					time.Date(0, 0, 0, 11, 30, 0, 0, time.UTC),
+					model.Sits,
+					model.NewClientSits("client2", 1, p.coreData.TablesCount),
+				))
+				p.tables.Set(2, model.NewIncomingEvent(
+					time.Date(0, 0, 0, 11, 30, 0, 0, time.UTC),
+					model.Sits,
+					model.NewClientSits("client3", 2, p.coreData.TablesCount),
+				))
+
+				p.waitingQueue.Push(model.NewClientWaits("client1"))
+			},
+			check: func(p *EventProcessorImpl) {
+				s.Equal(1, p.waitingQueue.Len())
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -479,6 +520,50 @@ func (s *processorTestSuite) TestProcessWaits() {
 	}
 }
 
+func (s *processorTestSuite) TestWaitsWithFullQueue() {
+	at := func(hour, minute int) time.Time {
+		return time.Date(0, 0, 0, hour, minute, 0, 0, time.UTC)
+	}
+
+	p := newProcessorWithCoreData(s, model.NewCoreData(1, 10, model.NewTimeInterval(at(9, 0), at(19, 0))))
+
+	var written []string
+	p.AddListener(func(event ifces.TimeFormatter) {
+		written = append(written, event.String(p.cfg.TimeFormat))
+	})
+
+	p.Apply(model.NewIncomingEvent(at(9, 10), model.Arrives, model.NewClientArrives("client1")))
+	p.Apply(model.NewIncomingEvent(at(9, 11), model.Sits, model.NewClientSits("client1", 1, 1)))
+	p.Apply(model.NewIncomingEvent(at(9, 20), model.Arrives, model.NewClientArrives("client2")))
+	p.Apply(model.NewIncomingEvent(at(9, 21), model.Waits, model.NewClientWaits("client2")))
+	p.Apply(model.NewIncomingEvent(at(9, 30), model.Arrives, model.NewClientArrives("client3")))
+	p.Apply(model.NewIncomingEvent(at(9, 31), model.Waits, model.NewClientWaits("client3")))
+	p.Apply(model.NewIncomingEvent(at(9, 55), model.Waits, model.NewClientWaits("client2")))
+	p.Apply(model.NewIncomingEvent(at(9, 56), model.Waits, model.NewClientWaits("client1")))
+	s.Equal([]TableState{{Table: 1, Client: "client1", Since: at(9, 11)}}, p.State().Tables)
+
+	p.Apply(model.NewIncomingEvent(at(10, 0), model.Leaves, model.NewClientLeaves("client1")))
+	p.Finish()
+
+	// waiting again keeps the client in the queue, the client turned away by the full queue leaves once,
+	// the seated client keeps the table
+	s.Equal([]string{
+		"09:10 1 client1",
+		"09:11 2 client1 1",
+		"09:20 1 client2",
+		"09:21 3 client2",
+		"09:30 1 client3",
+		"09:31 3 client3",
+		"09:31 11 client3",
+		"09:55 3 client2",
+		"09:56 3 client1",
+		"09:56 11 client1",
+		"10:00 4 client1",
+		"10:00 12 client2 1",
+		"19:00 11 client2",
+	}, written)
+}
+
 func (s *processorTestSuite) TestProcessLeaves() {
 	testCases := []struct {
 		name          string
@@ -580,8 +665,11 @@ func (s *processorTestSuite) TestProcessLeaves() {
 			},
 			buildRevenue: func(p *EventProcessorImpl) *model.RevenueStats {
 				return &model.RevenueStats{
-					Income:    p.coreData.PricePerHour * 2,
-					UsageTime: time.Duration(2) * time.Hour,
+					Income:        p.coreData.PricePerHour * 2,
+					UsageTime:     time.Duration(2) * time.Hour,
+					Sessions:      1,
+					FirstOccupied: time.Date(0, 0, 0, 10, 0, 0, 0, time.UTC),
+					LastReleased:  time.Date(0, 0, 0, 12, 0, 0, 0, time.UTC),
 				}
 			},
 			prevTable: 1,
@@ -631,12 +719,80 @@ func (s *processorTestSuite) TestProcessLeaves() {
 			},
 			buildRevenue: func(p *EventProcessorImpl) *model.RevenueStats {
 				return &model.RevenueStats{
-					Income:    p.coreData.PricePerHour * 2,
-					UsageTime: time.Duration(1)*time.Hour + time.Duration(1)*time.Minute,
+					Income:        p.coreData.PricePerHour * 2,
+					UsageTime:     time.Duration(1)*time.Hour + time.Duration(1)*time.Minute,
+					Sessions:      1,
+					FirstOccupied: time.Date(0, 0, 0, 10, 59, 0, 0, time.UTC),
+					LastReleased:  time.Date(0, 0, 0, 12, 0, 0, 0, time.UTC),
 				}
 			},
 			prevTable: 1,
 		},
+		{
+			name: "waiting client leaves",
+			buildEvent: func(p *EventProcessorImpl) *model.IncomingEvent {
+				return model.NewIncomingEvent(
+					time.Date(0, 0, 0, 12, 0, 0, 0, time.UTC),
+					model.Leaves,
+					model.NewClientLeaves("client2"),
+				)
+			},
+			buildExpected: func(p *EventProcessorImpl) string {
+				return ""
+			},
+			prep: func(p *EventProcessorImpl) {
+				p.clients.Set("client2", -1)
+				p.waitingQueue.Push(model.NewClientWaits("client3"))
+				p.waitingQueue.Push(model.NewClientWaits("client2"))
+				p.waitingQueue.Push(model.NewClientWaits("client4"))
+			},
+			check: func(p *EventProcessorImpl) {
+				s.Equal(0, p.clients.Len())
+				s.Equal(2, p.waitingQueue.Len())
+				s.False(p.waitingQueue.Contains(clientNamed("client2")))
+
+				top, err := p.waitingQueue.Peek()
+				s.NoError(err)
+				s.Equal("client3", top.GetName())
+			},
+		},
+		{
+			name: "waiting client leaves, isn't seated later",
+			buildEvent: func(p *EventProcessorImpl) *model.IncomingEvent {
+				return model.NewIncomingEvent(
+					time.Date(0, 0, 0, 12, 0, 0, 0, time.UTC),
+					model.Leaves,
+					model.NewClientLeaves("client1"),
+				)
+			},
+			buildExpected: func(p *EventProcessorImpl) string {
+				return model.NewClientLeftEvent(
+					time.Date(0, 0, 0, 12, 0, 0, 0, time.UTC),
+					model.NewClientLeaves("client1"),
+				).String(p.cfg.TimeFormat) + "\n"
+			},
+			prep: func(p *EventProcessorImpl) {
+				p.clients.Set("client1", 1)
+				p.tables.Set(1, model.NewIncomingEvent(
+					time.Date(0, 0, 0, 10, 0, 0, 0, time.UTC),
+					model.Sits,
+					model.NewClientSits("client1", 1, p.coreData.TablesCount),
+				))
+				p.clients.Set("client2", -1)
+				p.waitingQueue.Push(model.NewClientWaits("client2"))
+				p.processLeaves(model.NewIncomingEvent(
+					time.Date(0, 0, 0, 11, 0, 0, 0, time.UTC),
+					model.Leaves,
+					model.NewClientLeaves("client2"),
+				), false)
+			},
+			generateLeft: true,
+			check: func(p *EventProcessorImpl) {
+				s.Equal(0, p.clients.Len())
+				s.Equal(0, p.tables.Len())
+				s.Equal(0, p.waitingQueue.Len())
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -688,8 +844,11 @@ func (s *processorTestSuite) TestProcessRevenueUpdate() {
 			buildRevenue: func(p *EventProcessorImpl) map[int]*model.RevenueStats {
 				return map[int]*model.RevenueStats{
 					1: {
-						Income:    p.coreData.PricePerHour * 2,
-						UsageTime: time.Duration(2) * time.Hour,
+						Income:        p.coreData.PricePerHour * 2,
+						UsageTime:     time.Duration(2) * time.Hour,
+						Sessions:      1,
+						FirstOccupied: time.Date(0, 0, 0, 10, 0, 0, 0, time.UTC),
+						LastReleased:  time.Date(0, 0, 0, 12, 0, 0, 0, time.UTC),
 					},
 				}
 			},
@@ -722,16 +881,25 @@ func (s *processorTestSuite) TestProcessRevenueUpdate() {
 			buildRevenue: func(p *EventProcessorImpl) map[int]*model.RevenueStats {
 				return map[int]*model.RevenueStats{
 					1: {
-						Income:    p.coreData.PricePerHour * 2,
-						UsageTime: time.Duration(2) * time.Hour,
+						Income:        p.coreData.PricePerHour * 2,
+						UsageTime:     time.Duration(2) * time.Hour,
+						Sessions:      1,
+						FirstOccupied: time.Date(0, 0, 0, 10, 0, 0, 0, time.UTC),
+						LastReleased:  time.Date(0, 0, 0, 12, 0, 0, 0, time.UTC),
 					},
 					2: {
-						Income:    p.coreData.PricePerHour * 3,
-						UsageTime: time.Duration(3) * time.Hour,
+						Income:        p.coreData.PricePerHour * 3,
+						UsageTime:     time.Duration(3) * time.Hour,
+						Sessions:      1,
+						FirstOccupied: time.Date(0, 0, 0, 10, 0, 0, 0, time.UTC),
+						LastReleased:  time.Date(0, 0, 0, 13, 0, 0, 0, time.UTC),
 					},
 					3: {
-						Income:    p.coreData.PricePerHour * 4,
-						UsageTime: time.Duration(3)*time.Hour + time.Duration(49)*time.Minute,
+						Income:        p.coreData.PricePerHour * 4,
+						UsageTime:     time.Duration(3)*time.Hour + time.Duration(49)*time.Minute,
+						Sessions:      1,
+						FirstOccupied: time.Date(0, 0, 0, 10, 11, 0, 0, time.UTC),
+						LastReleased:  time.Date(0, 0, 0, 14, 0, 0, 0, time.UTC),
 					},
 				}
 			},
@@ -753,6 +921,38 @@ func (s *processorTestSuite) TestProcessRevenueUpdate() {
 				))
 			},
 		},
+		{
+			name:   "session crosses tariff",
+			tables: []int{1},
+			happensAt: []time.Time{
+				time.Date(0, 0, 0, 12, 30, 0, 0, time.UTC),
+			},
+			buildRevenue: func(p *EventProcessorImpl) map[int]*model.RevenueStats {
+				return map[int]*model.RevenueStats{
+					1: {
+						Income:        p.coreData.PricePerHour + 30,
+						UsageTime:     time.Duration(90) * time.Minute,
+						Sessions:      1,
+						FirstOccupied: time.Date(0, 0, 0, 11, 0, 0, 0, time.UTC),
+						LastReleased:  time.Date(0, 0, 0, 12, 30, 0, 0, time.UTC),
+					},
+				}
+			},
+			prep: func(p *EventProcessorImpl) {
+				p.coreData.Tariffs = []*model.Tariff{
+					model.NewTariff(
+						time.Date(0, 0, 0, 12, 0, 0, 0, time.UTC),
+						time.Date(0, 0, 0, 18, 0, 0, 0, time.UTC),
+						30, "",
+					),
+				}
+				p.tables.Set(1, model.NewIncomingEvent(
+					time.Date(0, 0, 0, 11, 0, 0, 0, time.UTC),
+					model.Sits,
+					model.NewClientSits("client1", 1, p.coreData.TablesCount),
+				))
+			},
+		},
 		{
 			name:   "single table multiple events",
 			tables: []int{1, 1},
@@ -763,8 +963,11 @@ func (s *processorTestSuite) TestProcessRevenueUpdate() {
 			buildRevenue: func(p *EventProcessorImpl) map[int]*model.RevenueStats {
 				return map[int]*model.RevenueStats{
 					1: {
-						Income:    p.coreData.PricePerHour*2 + p.coreData.PricePerHour*3,
-						UsageTime: time.Duration(2)*time.Hour + time.Duration(3)*time.Hour,
+						Income:        p.coreData.PricePerHour*2 + p.coreData.PricePerHour*3,
+						UsageTime:     time.Duration(2)*time.Hour + time.Duration(3)*time.Hour,
+						Sessions:      2,
+						FirstOccupied: time.Date(0, 0, 0, 10, 0, 0, 0, time.UTC),
+						LastReleased:  time.Date(0, 0, 0, 13, 0, 0, 0, time.UTC),
 					},
 				}
 			},
@@ -882,3 +1085,924 @@ func (s *processorTestSuite) TestProcessEvents() {
 		})
 	}
 }
+
+func (s *processorTestSuite) TestProcessDays() {
+	workingTime := model.NewTimeInterval(
+		time.Date(0, 1, 1, 20, 0, 0, 0, time.UTC),
+		time.Date(0, 1, 1, 4, 0, 0, 0, time.UTC),
+	)
+
+	// at locates the clock time on the working day as the parser does
+	at := func(day, hour, minute int) time.Time {
+		return workingTime.AddDays(day).Locate(time.Date(0, 1, 1, hour, minute, 0, 0, time.UTC))
+	}
+
+	events := []model.WrappedIncomingEvent{
+		{Event: model.NewIncomingEvent(at(0, 21, 0), model.Arrives, model.NewClientArrives("client1"))},
+		{Event: model.NewIncomingEvent(at(0, 21, 5), model.Sits, model.NewClientSits("client1", 1, 2))},
+		{Event: model.NewIncomingEvent(at(0, 1, 30), model.Leaves, model.NewClientLeaves("client1"))},
+		{Event: model.NewIncomingEvent(at(1, 22, 0), model.Arrives, model.NewClientArrives("client2")), Day: 1},
+		{Event: model.NewIncomingEvent(at(1, 22, 0), model.Sits, model.NewClientSits("client2", 2, 2)), Day: 1},
+		{Event: model.NewIncomingEvent(at(1, 3, 0), model.Arrives, model.NewClientArrives("client1")), Day: 1},
+		{Event: model.NewIncomingEvent(at(1, 3, 30), model.Sits, model.NewClientSits("client1", 1, 2)), Day: 1},
+		{Event: model.NewIncomingEvent(at(3, 21, 0), model.Arrives, model.NewClientArrives("client3")), Day: 3},
+	}
+
+	p := newProcessorWithCoreData(s, model.NewCoreData(2, 10, workingTime))
+
+	eventsChan := make(chan model.WrappedIncomingEvent, len(events))
+	for _, event := range events {
+		eventsChan <- event
+	}
+	close(eventsChan)
+
+	s.Require().NoError(p.ProcessEvents(eventsChan))
+	p.ShowRevenue()
+
+	s.Equal(strings.Join([]string{
+		"20:00",
+		"21:00 1 client1",
+		"21:05 2 client1 1",
+		"01:30 4 client1",
+		"04:00",
+		"1 50 04:25",
+		"20:00",
+		"22:00 1 client2",
+		"22:00 2 client2 2",
+		"03:00 1 client1",
+		"03:30 2 client1 1",
+		"04:00 11 client1",
+		"04:00 11 client2",
+		"04:00",
+		"1 10 00:30",
+		"2 60 06:00",
+		"20:00",
+		"04:00",
+		"20:00",
+		"21:00 1 client3",
+		"04:00 11 client3",
+		"04:00",
+	}, "\n")+"\n", s.getOutEvent(p))
+
+	// results of the finished days are kept
+	days := p.Days()
+	s.Require().Len(days, 4)
+
+	var incomes []int
+	for idx, day := range days {
+		s.Equal(workingTime.AddDays(idx), day.WorkingTime)
+
+		income := 0
+		for _, pair := range day.Revenue.GetAll() {
+			income += pair.Value.Income
+		}
+
+		incomes = append(incomes, income)
+	}
+
+	s.Equal([]int{50, 70, 0, 0}, incomes)
+	s.Len(days[1].Sessions, 2)
+}
+
+func (s *processorTestSuite) TestDaylightSavingTime() {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	s.Require().NoError(err)
+
+	clock := func(hour, minute int) time.Time {
+		return time.Date(0, 1, 1, hour, minute, 0, 0, time.UTC)
+	}
+
+	testCases := []struct {
+		name      string
+		date      time.Time
+		expUsage  time.Duration
+		expIncome int
+	}{
+		{
+			name:      "clocks go forward",
+			date:      time.Date(2023, 3, 25, 0, 0, 0, 0, berlin),
+			expUsage:  3*time.Hour + 30*time.Minute,
+			expIncome: 40,
+		},
+		{
+			name:      "clocks go back",
+			date:      time.Date(2023, 10, 28, 0, 0, 0, 0, berlin),
+			expUsage:  5*time.Hour + 30*time.Minute,
+			expIncome: 60,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			workingTime := model.NewTimeInterval(clock(20, 0), clock(4, 0)).OnDate(tc.date)
+			p := newProcessorWithCoreData(s, model.NewCoreData(2, 10, workingTime))
+
+			p.Apply(model.NewIncomingEvent(workingTime.Locate(clock(23, 0)), model.Arrives, model.NewClientArrives("client1")))
+			p.Apply(model.NewIncomingEvent(workingTime.Locate(clock(23, 0)), model.Sits, model.NewClientSits("client1", 1, 2)))
+			p.Apply(model.NewIncomingEvent(workingTime.Locate(clock(3, 30)), model.Leaves, model.NewClientLeaves("client1")))
+
+			stats, ok := p.revenue.Get(1)
+			s.Require().True(ok)
+			s.Equal(tc.expUsage, stats.UsageTime)
+			s.Equal(tc.expIncome, stats.Income)
+		})
+	}
+}
+
+func (s *processorTestSuite) TestSessions() {
+	at := func(hour, minute int) time.Time {
+		return time.Date(0, 0, 0, hour, minute, 0, 0, time.UTC)
+	}
+
+	p := newProcessorWithCoreData(s, model.NewCoreData(2, 10, model.NewTimeInterval(at(10, 0), at(14, 0))))
+
+	p.Apply(model.NewIncomingEvent(at(10, 5), model.Arrives, model.NewClientArrives("client1")))
+	p.Apply(model.NewIncomingEvent(at(10, 10), model.Sits, model.NewClientSits("client1", 1, 2)))
+	p.Apply(model.NewIncomingEvent(at(10, 15), model.Arrives, model.NewClientArrives("client2")))
+	p.Apply(model.NewIncomingEvent(at(10, 20), model.Sits, model.NewClientSits("client1", 2, 2)))
+	p.Apply(model.NewIncomingEvent(at(10, 25), model.Sits, model.NewClientSits("client2", 1, 2)))
+	p.Apply(model.NewIncomingEvent(at(10, 30), model.Arrives, model.NewClientArrives("client3")))
+	p.Apply(model.NewIncomingEvent(at(10, 35), model.Waits, model.NewClientWaits("client3")))
+	p.Apply(model.NewIncomingEvent(at(11, 50), model.Leaves, model.NewClientLeaves("client1")))
+	p.Finish()
+
+	s.Equal([]*model.Session{
+		model.NewSession("client1", 1, at(10, 10), at(10, 20), time.Hour, 10),
+		model.NewSession("client1", 2, at(10, 20), at(11, 50), 2*time.Hour, 20),
+		model.NewSession("client2", 1, at(10, 25), at(14, 0), 4*time.Hour, 40),
+		model.NewSession("client3", 2, at(11, 50), at(14, 0), 3*time.Hour, 30),
+	}, p.Sessions())
+}
+
+func (s *processorTestSuite) TestLeaveClients() {
+	testCases := []struct {
+		name          string
+		prep          func(p *EventProcessorImpl)
+		buildExpected func(p *EventProcessorImpl) string
+	}{
+		{
+			name:          "no clients",
+			buildExpected: func(p *EventProcessorImpl) string { return "" },
+		},
+		{
+			name: "seated and waiting clients leave once",
+			prep: func(p *EventProcessorImpl) {
+				p.clients.Set("client1", 1)
+				p.tables.Set(1, model.NewIncomingEvent(
+					time.Date(0, 0, 0, 10, 0, 0, 0, time.UTC),
+					model.Sits,
+					model.NewClientSits("client1", 1, p.coreData.TablesCount),
+				))
+				p.clients.Set("client2", -1)
+				p.waitingQueue.Push(model.NewClientWaits("client2"))
+			},
+			buildExpected: func(p *EventProcessorImpl) string {
+				return model.NewClientLeftEvent(
+					p.coreData.WorkingTime.End,
+					model.NewClientLeaves("client1"),
+				).String(p.cfg.TimeFormat) + "\n" + model.NewClientLeftEvent(
+					p.coreData.WorkingTime.End,
+					model.NewClientLeaves("client2"),
+				).String(p.cfg.TimeFormat) + "\n"
+			},
+		},
+		{
+			name: "waiting client isn't in the club",
+			prep: func(p *EventProcessorImpl) {
+				p.waitingQueue.Push(model.NewClientWaits("client1"))
+			},
+			buildExpected: func(p *EventProcessorImpl) string {
+				return model.NewClientLeftEvent(
+					p.coreData.WorkingTime.End,
+					model.NewClientLeaves("client1"),
+				).String(p.cfg.TimeFormat) + "\n"
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			p := newDefProcessor(s)
+
+			if tc.prep != nil {
+				tc.prep(p)
+			}
+
+			p.leaveClients()
+			s.Equal(tc.buildExpected(p), s.getOutEvent(p))
+			s.Equal(0, p.clients.Len())
+			s.Equal(0, p.tables.Len())
+			s.Equal(0, p.waitingQueue.Len())
+		})
+	}
+}
+
+func (s *processorTestSuite) TestShowRevenueByCategory() {
+	p := newDefProcessor(s)
+	p.coreData.Categories = []*model.TableCategory{
+		model.NewTableCategory("vip", 30, []int{1, 2}),
+		model.NewTableCategory("console", 15, []int{4}),
+	}
+
+	for table := 1; table <= 3; table++ {
+		p.tables.Set(table, model.NewIncomingEvent(
+			time.Date(0, 0, 0, 10, 0, 0, 0, time.UTC),
+			model.Sits,
+			model.NewClientSits("client1", table, p.coreData.TablesCount),
+		))
+		p.updateRevenue(table, time.Date(0, 0, 0, 11, 30, 0, 0, time.UTC))
+	}
+
+	p.ShowRevenue()
+	s.Equal(strings.Join([]string{
+		"1 60 01:30",
+		"2 60 01:30",
+		"3 20 01:30",
+		"vip 120 03:00",
+		"console 0 00:00",
+	}, "\n")+"\n", s.getOutEvent(p))
+}
+
+func (s *processorTestSuite) TestState() {
+	p := newDefProcessor(s)
+
+	var written []string
+	p.AddListener(func(event ifces.TimeFormatter) {
+		written = append(written, event.String(p.cfg.TimeFormat))
+	})
+
+	at := func(hour, minute int) time.Time {
+		return time.Date(0, 0, 0, hour, minute, 0, 0, time.UTC)
+	}
+
+	p.coreData.TablesCount = 1
+	p.Apply(model.NewIncomingEvent(at(10, 1), model.Arrives, model.NewClientArrives("client2")))
+	p.Apply(model.NewIncomingEvent(at(10, 5), model.Arrives, model.NewClientArrives("client1")))
+	p.Apply(model.NewIncomingEvent(at(10, 10), model.Sits, model.NewClientSits("client1", 1, 1)))
+	p.Apply(model.NewIncomingEvent(at(10, 15), model.Waits, model.NewClientWaits("client2")))
+	p.Apply(model.NewIncomingEvent(at(10, 20), model.Sits, model.NewClientSits("client2", 1, 1)))
+
+	s.Equal([]string{
+		"10:01 1 client2",
+		"10:05 1 client1",
+		"10:10 2 client1 1",
+		"10:15 3 client2",
+		"10:20 2 client2 1",
+		"10:20 13 PlaceIsBusy",
+	}, written)
+
+	s.Equal(&State{
+		Tables:  []TableState{{Table: 1, Client: "client1", Since: at(10, 10)}},
+		Queue:   []string{"client2"},
+		Clients: []ClientState{{Name: "client1", Table: 1}, {Name: "client2"}},
+		Revenue: []TableRevenue{},
+
+		Reservations: []ReservationState{},
+	}, p.State())
+
+	p.Apply(model.NewIncomingEvent(at(11, 0), model.Leaves, model.NewClientLeaves("client1")))
+
+	s.Equal(&State{
+		Tables:  []TableState{{Table: 1, Client: "client2", Since: at(11, 0)}},
+		Queue:   []string{},
+		Clients: []ClientState{{Name: "client2", Table: 1}},
+		Revenue: []TableRevenue{{Table: 1, Stats: model.RevenueStats{
+			Income:        10,
+			UsageTime:     50 * time.Minute,
+			Sessions:      1,
+			FirstOccupied: at(10, 10),
+			LastReleased:  at(11, 0),
+		}}},
+
+		Reservations: []ReservationState{},
+		Sessions:     []model.Session{*model.NewSession("client1", 1, at(10, 10), at(11, 0), time.Hour, 10)},
+	}, p.State())
+
+	// the state restored on another processor is the same
+	restored := newDefProcessor(s)
+	restored.coreData.TablesCount = 1
+	restored.Restore(p.State())
+	s.Equal(p.State(), restored.State())
+	s.Equal(p.Sessions(), restored.Sessions())
+}
+
+func (s *processorTestSuite) TestStateAt() {
+	at := func(hour, minute int) time.Time {
+		return time.Date(0, 0, 0, hour, minute, 0, 0, time.UTC)
+	}
+
+	newEvents := func(events ...model.WrappedIncomingEvent) <-chan model.WrappedIncomingEvent {
+		ch := make(chan model.WrappedIncomingEvent, len(events))
+		for _, event := range events {
+			ch <- event
+		}
+
+		close(ch)
+		return ch
+	}
+
+	events := []model.WrappedIncomingEvent{
+		{Event: model.NewIncomingEvent(at(10, 5), model.Arrives, model.NewClientArrives("client1"))},
+		{Event: model.NewIncomingEvent(at(10, 10), model.Sits, model.NewClientSits("client1", 1, 10))},
+		{Event: model.NewIncomingEvent(at(11, 30), model.Sits, model.NewClientSits("client1", 2, 10))},
+		{Event: model.NewIncomingEvent(at(13, 0), model.Leaves, model.NewClientLeaves("client1"))},
+	}
+
+	testCases := []struct {
+		name   string
+		events []model.WrappedIncomingEvent
+		at     time.Time
+		exp    *State
+		expErr error
+	}{
+		{
+			name:   "before the first event",
+			events: events,
+			at:     at(10, 0),
+			exp: &State{
+				Tables:  []TableState{},
+				Queue:   []string{},
+				Clients: []ClientState{},
+				Revenue: []TableRevenue{},
+
+				Reservations: []ReservationState{},
+			},
+		},
+		{
+			name:   "session in progress",
+			events: events,
+			at:     at(12, 0),
+			exp: &State{
+				Tables:  []TableState{{Table: 2, Client: "client1", Since: at(11, 30)}},
+				Queue:   []string{},
+				Clients: []ClientState{{Name: "client1", Table: 2}},
+				Revenue: []TableRevenue{
+					{Table: 1, Stats: model.RevenueStats{
+						Income:        20,
+						UsageTime:     80 * time.Minute,
+						Sessions:      1,
+						FirstOccupied: at(10, 10),
+						LastReleased:  at(11, 30),
+					}},
+					{Table: 2, Stats: model.RevenueStats{
+						Income:        10,
+						UsageTime:     30 * time.Minute,
+						Sessions:      1,
+						FirstOccupied: at(11, 30),
+					}},
+				},
+
+				Reservations: []ReservationState{},
+				Sessions:     []model.Session{*model.NewSession("client1", 1, at(10, 10), at(11, 30), 2*time.Hour, 20)},
+			},
+		},
+		{
+			name:   "event at the moment is applied",
+			events: events,
+			at:     at(10, 10),
+			exp: &State{
+				Tables:  []TableState{{Table: 1, Client: "client1", Since: at(10, 10)}},
+				Queue:   []string{},
+				Clients: []ClientState{{Name: "client1", Table: 1}},
+				Revenue: []TableRevenue{{Table: 1, Stats: model.RevenueStats{
+					Sessions:      1,
+					FirstOccupied: at(10, 10),
+				}}},
+
+				Reservations: []ReservationState{},
+			},
+		},
+		{
+			name: "closed club",
+			events: []model.WrappedIncomingEvent{
+				{Event: model.NewIncomingEvent(at(10, 5), model.Arrives, model.NewClientArrives("client1"))},
+				{Event: model.NewIncomingEvent(at(10, 10), model.Sits, model.NewClientSits("client1", 1, 10))},
+			},
+			at: at(15, 0),
+			exp: &State{
+				Tables:  []TableState{},
+				Queue:   []string{},
+				Clients: []ClientState{},
+				Revenue: []TableRevenue{{Table: 1, Stats: model.RevenueStats{
+					Income:        40,
+					UsageTime:     3*time.Hour + 50*time.Minute,
+					Sessions:      1,
+					FirstOccupied: at(10, 10),
+					LastReleased:  at(14, 0),
+				}}},
+
+				Reservations: []ReservationState{},
+				Sessions:     []model.Session{*model.NewSession("client1", 1, at(10, 10), at(14, 0), 4*time.Hour, 40)},
+			},
+		},
+		{
+			name: "error after the moment",
+			events: append(events[:1:1], model.WrappedIncomingEvent{
+				Err: &apierror.ParseError{RowNumber: 5, UserMsg: apierror.ErrEventInvalidFormat},
+			}),
+			at:     at(10, 5),
+			expErr: &apierror.ParseError{RowNumber: 5, UserMsg: apierror.ErrEventInvalidFormat},
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			p := newDefProcessor(s)
+
+			state, err := p.StateAt(newEvents(tc.events...), tc.at)
+			s.Require().Equal(tc.expErr, err)
+			s.Require().Equal(tc.exp, state)
+		})
+	}
+}
+
+func (s *processorTestSuite) TestQueuePolicy() {
+	testCases := []struct {
+		name           string
+		policy         string
+		maxQueueLength int
+		expSat         string
+		expLeft        []string
+		expQueue       []string
+	}{
+		{
+			name:     "fifo, queue length is the tables count",
+			policy:   QueueFIFO,
+			expSat:   "client2",
+			expLeft:  []string{"member1", "client3"},
+			expQueue: []string{},
+		},
+		{
+			name:           "fifo, max queue length",
+			policy:         QueueFIFO,
+			maxQueueLength: 2,
+			expSat:         "client2",
+			expLeft:        []string{"client3"},
+			expQueue:       []string{"member1"},
+		},
+		{
+			name:           "members go first",
+			policy:         QueuePriority,
+			maxQueueLength: 2,
+			expSat:         "member1",
+			expLeft:        []string{"client3"},
+			expQueue:       []string{"client2"},
+		},
+	}
+
+	at := func(minute int) time.Time {
+		return time.Date(0, 1, 1, 10, minute, 0, 0, time.UTC)
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			cfg := *s.cfg
+			cfg.QueuePolicy = tc.policy
+			cfg.MaxQueueLength = tc.maxQueueLength
+
+			coreData := model.NewCoreData(1, 10, model.NewTimeInterval(at(0), at(59)))
+			coreData.Members = []string{"member1"}
+
+			out := &bytes.Buffer{}
+			p := NewEventProcessor(
+				out,
+				&cfg,
+				coreData,
+				billing.NewHourly(),
+				storage.NewInMemoryStorage[int, *model.IncomingEvent](),
+				storage.NewInMemoryStorage[int, *model.RevenueStats](),
+				storage.NewInMemoryStorage[string, int](),
+				NewWaitingQueue(cfg.QueuePolicy, coreData),
+			)
+
+			for _, name := range []string{"client1", "client2", "member1", "client3"} {
+				p.Apply(model.NewIncomingEvent(at(1), model.Arrives, model.NewClientArrives(name)))
+			}
+
+			p.Apply(model.NewIncomingEvent(at(2), model.Sits, model.NewClientSits("client1", 1, 1)))
+			for _, name := range []string{"client2", "member1", "client3"} {
+				p.Apply(model.NewIncomingEvent(at(3), model.Waits, model.NewClientWaits(name)))
+			}
+
+			p.Apply(model.NewIncomingEvent(at(4), model.Leaves, model.NewClientLeaves("client1")))
+
+			for _, name := range tc.expLeft {
+				s.Contains(out.String(), "10:03 11 "+name+"\n")
+			}
+
+			s.Contains(out.String(), "10:04 12 "+tc.expSat+" 1\n")
+			s.Equal(tc.expQueue, p.State().Queue)
+		})
+	}
+}
+
+func (s *processorTestSuite) TestQueueReservationsFirst() {
+	at := func(minute int) time.Time {
+		return time.Date(0, 1, 1, 10, minute, 0, 0, time.UTC)
+	}
+
+	cfg := *s.cfg
+	cfg.QueuePolicy = QueuePriority
+	cfg.MaxQueueLength = 3
+
+	coreData := model.NewCoreData(2, 10, model.NewTimeInterval(at(0), at(59)))
+	coreData.Members = []string{"member1"}
+
+	newProcessor := func(out io.Writer) *EventProcessorImpl {
+		return NewEventProcessor(
+			out,
+			&cfg,
+			coreData,
+			billing.NewHourly(),
+			storage.NewInMemoryStorage[int, *model.IncomingEvent](),
+			storage.NewInMemoryStorage[int, *model.RevenueStats](),
+			storage.NewInMemoryStorage[string, int](),
+			NewWaitingQueue(cfg.QueuePolicy, coreData),
+		)
+	}
+
+	out := &bytes.Buffer{}
+	p := newProcessor(out)
+
+	for _, name := range []string{"client1", "client2", "client3", "member1", "client4"} {
+		p.Apply(model.NewIncomingEvent(at(1), model.Arrives, model.NewClientArrives(name)))
+	}
+
+	p.Apply(model.NewIncomingEvent(at(2), model.Sits, model.NewClientSits("client1", 1, 2)))
+	p.Apply(model.NewIncomingEvent(at(2), model.Sits, model.NewClientSits("client2", 2, 2)))
+	for _, name := range []string{"client3", "member1", "client4"} {
+		p.Apply(model.NewIncomingEvent(at(3), model.Waits, model.NewClientWaits(name)))
+	}
+
+	// the waiting client, who reserves a table, moves to the reservations lane
+	slot := model.NewTimeInterval(at(30), at(45))
+	p.Apply(model.NewIncomingEvent(at(4), model.Reserves, model.NewClientReserves("client4", 2, 2, slot)))
+	s.Equal([]string{"client4", "member1", "client3"}, p.State().Queue)
+
+	// the restored clients are queued in their lanes
+	state := p.State()
+	state.Queue = []string{"client3", "member1", "client4"}
+
+	restored := newProcessor(io.Discard)
+	restored.Restore(state)
+	s.Equal([]string{"client4", "member1", "client3"}, restored.State().Queue)
+
+	p.Apply(model.NewIncomingEvent(at(5), model.Leaves, model.NewClientLeaves("client1")))
+	s.Contains(out.String(), "10:05 12 client4 1\n")
+	s.Equal([]string{"member1", "client3"}, p.State().Queue)
+}
+
+func (s *processorTestSuite) TestReservations() {
+	at := func(hour, minute int) time.Time {
+		return time.Date(0, 0, 0, hour, minute, 0, 0, time.UTC)
+	}
+
+	arrives := func(t time.Time, name string) *model.IncomingEvent {
+		return model.NewIncomingEvent(t, model.Arrives, model.NewClientArrives(name))
+	}
+
+	sits := func(t time.Time, name string, table int) *model.IncomingEvent {
+		return model.NewIncomingEvent(t, model.Sits, model.NewClientSits(name, table, 2))
+	}
+
+	waits := func(t time.Time, name string) *model.IncomingEvent {
+		return model.NewIncomingEvent(t, model.Waits, model.NewClientWaits(name))
+	}
+
+	leaves := func(t time.Time, name string) *model.IncomingEvent {
+		return model.NewIncomingEvent(t, model.Leaves, model.NewClientLeaves(name))
+	}
+
+	reserves := func(t time.Time, name string, table int, from, to time.Time) *model.IncomingEvent {
+		return model.NewIncomingEvent(t, model.Reserves, model.NewClientReserves(name, table, 2, model.NewTimeInterval(from, to)))
+	}
+
+	testCases := []struct {
+		name   string
+		events []*model.IncomingEvent
+		exp    []string
+	}{
+		{
+			name: "others can't sit during the reservation",
+			events: []*model.IncomingEvent{
+				reserves(at(10, 1), "client1", 1, at(12, 0), at(13, 0)),
+				arrives(at(10, 2), "client2"),
+				sits(at(11, 0), "client2", 1),
+				leaves(at(11, 59), "client2"),
+				arrives(at(12, 1), "client3"),
+				sits(at(12, 2), "client3", 1),
+				arrives(at(12, 10), "client1"),
+				sits(at(12, 11), "client1", 1),
+			},
+			exp: []string{
+				"10:01 5 client1 1 12:00 13:00",
+				"10:02 1 client2",
+				"11:00 2 client2 1",
+				"11:59 4 client2",
+				"12:01 1 client3",
+				"12:02 2 client3 1",
+				"12:02 13 TableIsReserved",
+				"12:10 1 client1",
+				"12:11 2 client1 1",
+			},
+		},
+		{
+			name: "no-show releases the table to the waiting client",
+			events: []*model.IncomingEvent{
+				reserves(at(10, 1), "client1", 1, at(12, 0), at(13, 0)),
+				arrives(at(10, 2), "client2"),
+				sits(at(10, 3), "client2", 2),
+				arrives(at(12, 4), "client3"),
+				waits(at(12, 5), "client3"),
+				leaves(at(12, 30), "client2"),
+			},
+			exp: []string{
+				"10:01 5 client1 1 12:00 13:00",
+				"10:02 1 client2",
+				"10:03 2 client2 2",
+				"12:04 1 client3",
+				"12:05 3 client3",
+				"12:15 12 client3 1",
+				"12:30 4 client2",
+			},
+		},
+		{
+			name: "client at the reserved table leaves at the slot start, if the reserving client waits and all tables are busy",
+			events: []*model.IncomingEvent{
+				reserves(at(10, 1), "client1", 1, at(12, 0), at(13, 0)),
+				arrives(at(10, 2), "client2"),
+				sits(at(11, 0), "client2", 1),
+				arrives(at(11, 1), "client3"),
+				sits(at(11, 2), "client3", 2),
+				arrives(at(11, 3), "client4"),
+				waits(at(11, 4), "client4"),
+				arrives(at(11, 50), "client1"),
+				waits(at(11, 55), "client1"),
+				leaves(at(12, 5), "client3"),
+			},
+			exp: []string{
+				"10:01 5 client1 1 12:00 13:00",
+				"10:02 1 client2",
+				"11:00 2 client2 1",
+				"11:01 1 client3",
+				"11:02 2 client3 2",
+				"11:03 1 client4",
+				"11:04 3 client4",
+				"11:50 1 client1",
+				"11:55 3 client1",
+				"12:00 11 client2",
+				"12:00 12 client1 1",
+				"12:05 4 client3",
+				"12:05 12 client4 2",
+			},
+		},
+		{
+			name: "client at the reserved table moves to a free table, when the reserving client sits down",
+			events: []*model.IncomingEvent{
+				arrives(at(10, 1), "client1"),
+				sits(at(10, 2), "client1", 1),
+				reserves(at(10, 3), "client2", 1, at(12, 0), at(13, 30)),
+				arrives(at(12, 5), "client2"),
+				sits(at(12, 6), "client2", 1),
+			},
+			exp: []string{
+				"10:01 1 client1",
+				"10:02 2 client1 1",
+				"10:03 5 client2 1 12:00 13:30",
+				"12:05 1 client2",
+				"12:06 2 client2 1",
+				"12:06 12 client1 2",
+			},
+		},
+		{
+			name: "client at the reserved table leaves, when the reserving client waits during the grace time",
+			events: []*model.IncomingEvent{
+				reserves(at(10, 1), "client1", 1, at(12, 0), at(13, 0)),
+				arrives(at(11, 0), "client2"),
+				sits(at(11, 1), "client2", 1),
+				arrives(at(11, 2), "client3"),
+				sits(at(11, 3), "client3", 2),
+				arrives(at(12, 5), "client1"),
+				waits(at(12, 6), "client1"),
+			},
+			exp: []string{
+				"10:01 5 client1 1 12:00 13:00",
+				"11:00 1 client2",
+				"11:01 2 client2 1",
+				"11:02 1 client3",
+				"11:03 2 client3 2",
+				"12:05 1 client1",
+				"12:06 3 client1",
+				"12:06 11 client2",
+				"12:06 12 client1 1",
+			},
+		},
+		{
+			name: "client at the reserved table keeps it, if the reserving client doesn't come",
+			events: []*model.IncomingEvent{
+				arrives(at(10, 1), "client1"),
+				sits(at(10, 2), "client1", 1),
+				reserves(at(10, 3), "client2", 1, at(12, 0), at(13, 30)),
+				arrives(at(12, 5), "client3"),
+				sits(at(12, 6), "client3", 1),
+				arrives(at(12, 20), "client2"),
+				sits(at(12, 21), "client2", 1),
+				leaves(at(12, 30), "client1"),
+			},
+			exp: []string{
+				"10:01 1 client1",
+				"10:02 2 client1 1",
+				"10:03 5 client2 1 12:00 13:30",
+				"12:05 1 client3",
+				"12:06 2 client3 1",
+				"12:06 13 PlaceIsBusy",
+				"12:20 1 client2",
+				"12:21 2 client2 1",
+				"12:21 13 PlaceIsBusy",
+				"12:30 4 client1",
+			},
+		},
+		{
+			name: "reserving client at the table before the slot claims the reservation",
+			events: []*model.IncomingEvent{
+				reserves(at(10, 1), "client1", 1, at(12, 0), at(13, 0)),
+				arrives(at(11, 50), "client1"),
+				sits(at(11, 51), "client1", 1),
+				arrives(at(12, 30), "client2"),
+				sits(at(13, 30), "client2", 2),
+			},
+			exp: []string{
+				"10:01 5 client1 1 12:00 13:00",
+				"11:50 1 client1",
+				"11:51 2 client1 1",
+				"12:30 1 client2",
+				"13:30 2 client2 2",
+			},
+		},
+		{
+			name: "overlapping and out of hours reservations",
+			events: []*model.IncomingEvent{
+				reserves(at(10, 1), "client1", 1, at(12, 0), at(13, 0)),
+				reserves(at(10, 2), "client2", 1, at(12, 30), at(13, 30)),
+				reserves(at(10, 3), "client2", 2, at(12, 30), at(13, 30)),
+				reserves(at(10, 4), "client3", 1, at(13, 30), at(15, 0)),
+				reserves(at(12, 0), "client3", 1, at(11, 0), at(11, 30)),
+			},
+			exp: []string{
+				"10:01 5 client1 1 12:00 13:00",
+				"10:02 5 client2 1 12:30 13:30",
+				"10:02 13 ReservationOverlaps",
+				"10:03 5 client2 2 12:30 13:30",
+				"10:04 5 client3 1 13:30 15:00",
+				"10:04 13 ReservationOutOfHours",
+				"12:00 5 client3 1 11:00 11:30",
+				"12:00 13 ReservationOutOfHours",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			p := newProcessorWithCoreData(s, model.NewCoreData(2, 10, model.NewTimeInterval(at(10, 0), at(14, 0))))
+
+			var written []string
+			p.AddListener(func(event ifces.TimeFormatter) {
+				written = append(written, event.String(p.cfg.TimeFormat))
+			})
+
+			for _, event := range tc.events {
+				p.Apply(event)
+			}
+
+			s.Require().Equal(tc.exp, written)
+		})
+	}
+}
+
+func (s *processorTestSuite) TestBalances() {
+	at := func(hour, minute int) time.Time {
+		return time.Date(0, 0, 0, hour, minute, 0, 0, time.UTC)
+	}
+
+	arrives := func(t time.Time, name string) *model.IncomingEvent {
+		return model.NewIncomingEvent(t, model.Arrives, model.NewClientArrives(name))
+	}
+
+	sits := func(t time.Time, name string, table int) *model.IncomingEvent {
+		return model.NewIncomingEvent(t, model.Sits, model.NewClientSits(name, table, 2))
+	}
+
+	waits := func(t time.Time, name string) *model.IncomingEvent {
+		return model.NewIncomingEvent(t, model.Waits, model.NewClientWaits(name))
+	}
+
+	leaves := func(t time.Time, name string) *model.IncomingEvent {
+		return model.NewIncomingEvent(t, model.Leaves, model.NewClientLeaves(name))
+	}
+
+	testCases := []struct {
+		name        string
+		accounts    []*model.ClientAccount
+		events      []*model.IncomingEvent
+		exp         []string
+		expBalances []BalanceState
+	}{
+		{
+			name:     "client leaves, when the balance is exhausted",
+			accounts: []*model.ClientAccount{model.NewClientAccount("client1", 20)},
+			events: []*model.IncomingEvent{
+				arrives(at(10, 1), "client1"),
+				sits(at(10, 2), "client1", 1),
+				arrives(at(12, 30), "client2"),
+			},
+			exp: []string{
+				"10:01 1 client1",
+				"10:02 2 client1 1",
+				"12:02 14 client1",
+				"12:30 1 client2",
+				"14:00 11 client2",
+			},
+			expBalances: []BalanceState{{Client: "client1", Balance: 0}},
+		},
+		{
+			name:     "waiting client takes the table of the client out of balance",
+			accounts: []*model.ClientAccount{model.NewClientAccount("client1", 10)},
+			events: []*model.IncomingEvent{
+				arrives(at(10, 1), "client1"),
+				sits(at(10, 2), "client1", 1),
+				arrives(at(10, 3), "client2"),
+				sits(at(10, 4), "client2", 2),
+				arrives(at(10, 5), "client3"),
+				waits(at(10, 6), "client3"),
+				leaves(at(11, 30), "client2"),
+			},
+			exp: []string{
+				"10:01 1 client1",
+				"10:02 2 client1 1",
+				"10:03 1 client2",
+				"10:04 2 client2 2",
+				"10:05 1 client3",
+				"10:06 3 client3",
+				"11:02 14 client1",
+				"11:02 12 client3 1",
+				"11:30 4 client2",
+				"14:00 11 client3",
+			},
+			expBalances: []BalanceState{{Client: "client1", Balance: 0}},
+		},
+		{
+			name:     "balance is charged on the table change",
+			accounts: []*model.ClientAccount{model.NewClientAccount("client1", 30)},
+			events: []*model.IncomingEvent{
+				arrives(at(10, 1), "client1"),
+				sits(at(10, 2), "client1", 1),
+				sits(at(10, 30), "client1", 2),
+			},
+			exp: []string{
+				"10:01 1 client1",
+				"10:02 2 client1 1",
+				"10:30 2 client1 2",
+				"12:30 14 client1",
+			},
+			expBalances: []BalanceState{{Client: "client1", Balance: 0}},
+		},
+		{
+			name: "balance covers the session till the closing",
+			accounts: []*model.ClientAccount{
+				model.NewClientAccount("client1", 100),
+				model.NewClientAccount("client2", 50),
+			},
+			events: []*model.IncomingEvent{
+				arrives(at(10, 1), "client1"),
+				sits(at(10, 2), "client1", 1),
+			},
+			exp: []string{
+				"10:01 1 client1",
+				"10:02 2 client1 1",
+				"14:00 11 client1",
+			},
+			expBalances: []BalanceState{{Client: "client1", Balance: 60}, {Client: "client2", Balance: 50}},
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			coreData := model.NewCoreData(2, 10, model.NewTimeInterval(at(10, 0), at(14, 0)))
+			coreData.Accounts = tc.accounts
+			p := newProcessorWithCoreData(s, coreData)
+
+			var written []string
+			p.AddListener(func(event ifces.TimeFormatter) {
+				written = append(written, event.String(p.cfg.TimeFormat))
+			})
+
+			for _, event := range tc.events {
+				p.Apply(event)
+			}
+			p.Finish()
+
+			s.Require().Equal(tc.exp, written)
+			s.Require().Equal(tc.expBalances, p.Balances())
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package processor
+
+import (
+	"yadro-intern/internal/model"
+	"yadro-intern/internal/storage"
+)
+
+// queue policies decide, in which order waiting clients take free tables
+const (
+	QueueFIFO     = "fifo"
+	QueuePriority = "priority"
+)
+
+// priorities of the waiting clients for the priority policy, the less is served first
+const (
+	reservationPriority = iota
+	memberPriority
+	walkInPriority
+)
+
+// IsKnownQueuePolicy checks, that the waiting queue of the policy exists.
+func IsKnownQueuePolicy(policy string) bool {
+	return policy == QueueFIFO || policy == QueuePriority
+}
+
+// NewWaitingQueue returns the queue of the clients waiting for a table,
+// FIFO queue is used for unknown policies.
+//
+// With the priority policy the clients with reservations are served first, then the club members
+// and other clients, clients of the same priority are served in the arrival order.
+// A client with the reservation is queued as his reservation, see waiting.
+func NewWaitingQueue(policy string, coreData *model.CoreData) storage.Queue[model.ClientData] {
+	if policy != QueuePriority {
+		return storage.NewInMemoryQueue[model.ClientData](nil)
+	}
+
+	return storage.NewPriorityQueue[model.ClientData](nil, func(client model.ClientData) int {
+		if _, ok := client.(*model.ClientReserves); ok {
+			return reservationPriority
+		}
+
+		if coreData.IsMember(client.GetName()) {
+			return memberPriority
+		}
+
+		return walkInPriority
+	})
+}
+
+// waiting returns the client data to queue for the client: his reservation,
+// which isn't claimed or released yet, or just the waiting client.
+func (p *EventProcessorImpl) waiting(name string) model.ClientData {
+	for _, reservation := range p.reservations {
+		if reservation.GetName() == name {
+			return reservation
+		}
+	}
+
+	return model.NewClientWaits(name)
+}
+
+// requeue moves the waiting client to the lane of the priority queue,
+// when his reservations change, FIFO queue keeps the arrival order.
+func (p *EventProcessorImpl) requeue(name string) {
+	if p.cfg.QueuePolicy != QueuePriority {
+		return
+	}
+
+	if p.waitingQueue.Remove(clientNamed(name)) {
+		p.waitingQueue.Push(p.waiting(name))
+	}
+}
+
+// maxQueueLength returns the count of clients, which can wait for a table.
+func (p *EventProcessorImpl) maxQueueLength() int {
+	if p.cfg.MaxQueueLength > 0 {
+		return p.cfg.MaxQueueLength
+	}
+
+	return p.coreData.TablesCount
+}
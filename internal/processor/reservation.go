@@ -0,0 +1,186 @@
+package processor
+
+import (
+	"errors"
+	"sort"
+	"time"
+	"yadro-intern/internal/apierror"
+	"yadro-intern/internal/model"
+)
+
+func (p *EventProcessorImpl) processReserves(event *model.IncomingEvent) {
+	reservation := event.Client.(*model.ClientReserves)
+	slot := reservation.GetSlot()
+
+	workingTime := p.workingTime()
+	if slot.Start.Before(workingTime.Start) || slot.End.After(workingTime.End) || !slot.End.After(event.HappensAt) {
+		outOfHours := model.NewErrorEvent(event.HappensAt, errors.New(apierror.ErrReservationOutOfHours))
+		p.writeOutEvent(outOfHours)
+		return
+	}
+
+	for _, other := range p.reservations {
+		if other.GetTable() == reservation.GetTable() && other.GetSlot().Overlaps(slot) {
+			overlaps := model.NewErrorEvent(event.HappensAt, errors.New(apierror.ErrReservationOverlaps))
+			p.writeOutEvent(overlaps)
+			return
+		}
+	}
+
+	p.reservations = append(p.reservations, reservation)
+	sort.SliceStable(p.reservations, func(i, j int) bool {
+		return p.reservations[i].GetSlot().Start.Before(p.reservations[j].GetSlot().Start)
+	})
+
+	p.requeue(reservation.GetName())
+}
+
+// reservationOf returns the reservation of the table active at the moment, nil if there is none.
+//
+// The reservation is active from the start of the slot until the client sits at the table,
+// or the grace time passes.
+func (p *EventProcessorImpl) reservationOf(table int, at time.Time) *model.ClientReserves {
+	for _, reservation := range p.reservations {
+		if reservation.GetTable() == table &&
+			!at.Before(reservation.GetSlot().Start) && at.Before(p.releaseTime(reservation)) {
+			return reservation
+		}
+	}
+
+	return nil
+}
+
+// releaseTime returns the moment, when the table is released, if the client hasn't come.
+func (p *EventProcessorImpl) releaseTime(reservation *model.ClientReserves) time.Time {
+	slot := reservation.GetSlot()
+	if release := slot.Start.Add(p.cfg.ReservationGrace); release.Before(slot.End) {
+		return release
+	}
+
+	return slot.End
+}
+
+// hasFreeTable checks, that the client can sit at some table at the moment.
+func (p *EventProcessorImpl) hasFreeTable(name string, at time.Time) bool {
+	for table := 1; table <= p.coreData.TablesCount; table++ {
+		if _, ok := p.tables.Get(table); ok {
+			continue
+		}
+
+		if reservation := p.reservationOf(table, at); reservation == nil || reservation.GetName() == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// nextExpired returns the reservation of the client, who hasn't come till the release time,
+// released first not later than at, nil if there is none.
+func (p *EventProcessorImpl) nextExpired(at time.Time) *model.ClientReserves {
+	var expired *model.ClientReserves
+	for _, reservation := range p.reservations {
+		release := p.releaseTime(reservation)
+		if !release.After(at) && (expired == nil || release.Before(p.releaseTime(expired))) {
+			expired = reservation
+		}
+	}
+
+	return expired
+}
+
+// expire removes the reservation, the released table is taken by the next waiting client.
+func (p *EventProcessorImpl) expire(expired *model.ClientReserves) {
+	p.removeReservation(expired)
+
+	release := p.releaseTime(expired)
+	if _, busy := p.tables.Get(expired.GetTable()); !busy && p.workingTime().In(release) {
+		p.seatWaiting(expired.GetTable(), release)
+	}
+}
+
+// nextStarted returns the reservation of the busy table, which slot has started first not later than at,
+// if the reserving client has come by then: he sits at the table or waits in the queue. nil if there is none.
+func (p *EventProcessorImpl) nextStarted(at time.Time) *model.ClientReserves {
+	for _, reservation := range p.reservations {
+		if reservation.GetSlot().Start.After(at) {
+			return nil
+		}
+
+		sitting, busy := p.tables.Get(reservation.GetTable())
+		if !busy {
+			continue
+		}
+
+		if sitting.Client.GetName() == reservation.GetName() ||
+			p.waitingQueue.Contains(clientNamed(reservation.GetName())) {
+			return reservation
+		}
+	}
+
+	return nil
+}
+
+// start gives the table to the reserving client at the start of the slot.
+//
+// If the reserving client sits at the table already, the reservation is claimed,
+// otherwise he waits in the queue and the client at the table is evicted.
+func (p *EventProcessorImpl) start(reservation *model.ClientReserves) {
+	sitting, _ := p.tables.Get(reservation.GetTable())
+	if sitting.Client.GetName() == reservation.GetName() {
+		p.removeReservation(reservation)
+		return
+	}
+
+	p.evict(reservation.GetTable(), reservation.GetSlot().Start)
+}
+
+// evict frees the reserved table, when the reserving client has come:
+// the client at the table moves to a free table or, if there is none, leaves the club.
+//
+// The freed table is taken by the reserving client, if he waits in the queue.
+func (p *EventProcessorImpl) evict(reserved int, at time.Time) {
+	sitting, _ := p.tables.Get(reserved)
+	client := sitting.Client.GetName()
+
+	for table := 1; table <= p.coreData.TablesCount; table++ {
+		if _, busy := p.tables.Get(table); busy {
+			continue
+		}
+
+		if other := p.reservationOf(table, at); other != nil && other.GetName() != client {
+			continue
+		}
+
+		moved := model.NewClientSits(client, table, p.coreData.TablesCount)
+		p.processSits(model.NewIncomingEvent(at, model.Sits, moved), true)
+		p.seatWaiting(reserved, at)
+		return
+	}
+
+	leaves := model.NewClientLeaves(client)
+	p.writeOutEvent(model.NewClientLeftEvent(at, leaves))
+	p.processLeaves(model.NewIncomingEvent(at, model.Leaves, leaves), false)
+}
+
+// reservationFor returns the reservation of the client active at the moment, nil if there is none.
+func (p *EventProcessorImpl) reservationFor(name string, at time.Time) *model.ClientReserves {
+	for _, reservation := range p.reservations {
+		if reservation.GetName() == name && reservation == p.reservationOf(reservation.GetTable(), at) {
+			return reservation
+		}
+	}
+
+	return nil
+}
+
+// removeReservation removes the reservation, when the client has sat down or hasn't come in time.
+func (p *EventProcessorImpl) removeReservation(removed *model.ClientReserves) {
+	for idx, reservation := range p.reservations {
+		if reservation == removed {
+			p.reservations = append(p.reservations[:idx], p.reservations[idx+1:]...)
+			p.requeue(removed.GetName())
+			return
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package processor
+
+import (
+	"sort"
+	"time"
+	"yadro-intern/internal/model"
+	"yadro-intern/internal/storage"
+)
+
+// State is the snapshot of the computer club.
+type State struct {
+
+	// Tables are the busy tables ordered by the table number.
+	Tables []TableState
+
+	// Queue is the waiting queue in the order clients will be seated.
+	Queue []string
+
+	// Clients are the clients in the club ordered by the name.
+	Clients []ClientState
+
+	// Revenue is the revenue of the used tables ordered by the table number.
+	Revenue []TableRevenue
+
+	// Reservations are the reservations, which aren't claimed or released yet, ordered by the start.
+	Reservations []ReservationState
+
+	// Balances are the balances of the clients with the accounts ordered by the name.
+	Balances []BalanceState
+
+	// Day is the number of the working day, 0 for the first day.
+	Day int
+
+	// Sessions are the finished sessions of the working day in the order they ended.
+	Sessions []model.Session
+}
+
+type TableState struct {
+	Table  int
+	Client string
+
+	// Since is the time, when the client sat at the table.
+	Since time.Time
+}
+
+type ClientState struct {
+	Name string
+
+	// Table is the table of the client, 0 if the client has no table.
+	Table int
+}
+
+type ReservationState struct {
+	Client string
+	Table  int
+	From   time.Time
+	To     time.Time
+}
+
+type BalanceState struct {
+	Client  string
+	Balance int
+}
+
+type TableRevenue struct {
+	Table int
+	Stats model.RevenueStats
+}
+
+// State returns the snapshot of the club after the applied events.
+func (p *EventProcessorImpl) State() *State {
+	state := &State{
+		Tables:  make([]TableState, 0, p.tables.Len()),
+		Queue:   make([]string, 0, p.waitingQueue.Len()),
+		Clients: make([]ClientState, 0, p.clients.Len()),
+		Revenue: make([]TableRevenue, 0, p.revenue.Len()),
+
+		Reservations: make([]ReservationState, 0, len(p.reservations)),
+	}
+
+	for _, pair := range p.tables.GetAll() {
+		state.Tables = append(state.Tables, TableState{
+			Table:  pair.Key,
+			Client: pair.Value.Client.GetName(),
+			Since:  pair.Value.HappensAt,
+		})
+	}
+
+	for _, client := range p.waitingQueue.GetAll() {
+		state.Queue = append(state.Queue, client.GetName())
+	}
+
+	for _, pair := range p.clients.GetAll() {
+		table := pair.Value
+		if table == -1 {
+			table = 0
+		}
+
+		state.Clients = append(state.Clients, ClientState{Name: pair.Key, Table: table})
+	}
+
+	for _, pair := range p.revenue.GetAll() {
+		state.Revenue = append(state.Revenue, TableRevenue{Table: pair.Key, Stats: *pair.Value})
+	}
+
+	for _, reservation := range p.reservations {
+		state.Reservations = append(state.Reservations, ReservationState{
+			Client: reservation.GetName(),
+			Table:  reservation.GetTable(),
+			From:   reservation.GetSlot().Start,
+			To:     reservation.GetSlot().End,
+		})
+	}
+
+	if len(p.balances) > 0 {
+		state.Balances = p.Balances()
+	}
+
+	state.Day = p.day
+	for _, session := range p.sessions {
+		state.Sessions = append(state.Sessions, *session)
+	}
+
+	sort.Slice(state.Tables, func(i, j int) bool {
+		return state.Tables[i].Table < state.Tables[j].Table
+	})
+
+	sort.Slice(state.Clients, func(i, j int) bool {
+		return state.Clients[i].Name < state.Clients[j].Name
+	})
+
+	sort.Slice(state.Revenue, func(i, j int) bool {
+		return state.Revenue[i].Table < state.Revenue[j].Table
+	})
+
+	return state
+}
+
+// StateAt applies the events happened not later than at and returns the state of the club at that moment.
+//
+// The moment belongs to the last working day opened by then, the earlier days are finished.
+// Revenue is the revenue of that day and includes the sessions in progress, as if they ended at that moment.
+// At the closing time and later all clients have left already.
+//
+// All events are read from the channel, so a format error of any event is returned.
+func (p *EventProcessorImpl) StateAt(events <-chan model.WrappedIncomingEvent, at time.Time) (*State, error) {
+	p.Start()
+
+	var err error
+	for wrapped := range events {
+		switch {
+		case err != nil:
+		case wrapped.Err != nil:
+			err = wrapped.Err
+		case !wrapped.Event.HappensAt.After(at):
+			for p.day < wrapped.Day {
+				p.nextDay()
+			}
+
+			p.Apply(wrapped.Event)
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for !at.Before(p.coreData.WorkingTime.AddDays(p.day + 1).Start) {
+		p.nextDay()
+	}
+
+	if !at.Before(p.workingTime().End) {
+		p.Finish()
+	} else {
+		p.advance(at)
+	}
+
+	state := p.State()
+	p.accrue(state, at)
+	return state, nil
+}
+
+// RevenueAt returns the revenue of the working day including the sessions in progress,
+// as if they ended at the moment, the processor isn't changed.
+func (p *EventProcessorImpl) RevenueAt(at time.Time) storage.Storage[int, *model.RevenueStats] {
+	state := p.State()
+	p.accrue(state, at)
+
+	revenue := storage.NewInMemoryStorage[int, *model.RevenueStats]()
+	for _, table := range state.Revenue {
+		stats := table.Stats
+		revenue.Set(table.Table, &stats)
+	}
+
+	return revenue
+}
+
+// accrue adds the sessions in progress to the revenue of the state, as if they ended at the moment.
+func (p *EventProcessorImpl) accrue(state *State, at time.Time) {
+	for _, table := range state.Tables {
+		idx := sort.Search(len(state.Revenue), func(i int) bool {
+			return state.Revenue[i].Table >= table.Table
+		})
+
+		if idx == len(state.Revenue) || state.Revenue[idx].Table != table.Table {
+			state.Revenue = append(state.Revenue, TableRevenue{})
+			copy(state.Revenue[idx+1:], state.Revenue[idx:])
+			state.Revenue[idx] = TableRevenue{
+				Table: table.Table,
+				Stats: model.RevenueStats{FirstOccupied: table.Since},
+			}
+		}
+
+		state.Revenue[idx].Stats = p.withSession(state.Revenue[idx].Stats, table.Table, table.Since, at)
+	}
+}
+
+// Restore replaces the state of the processor with the snapshot taken by State.
+func (p *EventProcessorImpl) Restore(state *State) {
+	for _, pair := range p.tables.GetAll() {
+		p.tables.Delete(pair.Key)
+	}
+
+	for _, pair := range p.clients.GetAll() {
+		p.clients.Delete(pair.Key)
+	}
+
+	for _, pair := range p.revenue.GetAll() {
+		p.revenue.Delete(pair.Key)
+	}
+
+	p.waitingQueue.Clear()
+
+	for _, table := range state.Tables {
+		sits := model.NewClientSits(table.Client, table.Table, p.coreData.TablesCount)
+		p.tables.Set(table.Table, model.NewIncomingEvent(table.Since, model.Sits, sits))
+	}
+
+	for _, client := range state.Clients {
+		table := client.Table
+		if table == 0 {
+			table = -1
+		}
+
+		p.clients.Set(client.Name, table)
+	}
+
+	for _, revenue := range state.Revenue {
+		stats := revenue.Stats
+		p.revenue.Set(revenue.Table, &stats)
+	}
+
+	p.reservations = make([]*model.ClientReserves, 0, len(state.Reservations))
+	for _, reservation := range state.Reservations {
+		slot := &model.TimeInterval{Start: reservation.From, End: reservation.To}
+		p.reservations = append(p.reservations, model.NewClientReserves(
+			reservation.Client, reservation.Table, p.coreData.TablesCount, slot,
+		))
+	}
+
+	// the clients with reservations are queued in their lane
+	for _, client := range state.Queue {
+		p.waitingQueue.Push(p.waiting(client))
+	}
+
+	p.balances = newBalances(p.coreData)
+	for _, balance := range state.Balances {
+		p.balances[balance.Client] = balance.Balance
+	}
+
+	p.day = state.Day
+	p.sessions = make([]*model.Session, 0, len(state.Sessions))
+	for _, session := range state.Sessions {
+		session := session
+		p.sessions = append(p.sessions, &session)
+	}
+}
@@ -0,0 +1,38 @@
+package report
+
+import (
+	"io"
+	"sort"
+	"yadro-intern/internal/model"
+	"yadro-intern/internal/processor"
+)
+
+// WriteBalances writes the balance of every client with the account ordered by the name:
+// the client name, the balance at the opening, the charged amount and the current balance.
+//
+//	client1 100 30 70
+//
+// The balance is below zero, if the minimal charge of the session is more than the client had.
+func WriteBalances(out io.Writer, coreData *model.CoreData, balances []processor.BalanceState) error {
+	current := make(map[string]int, len(balances))
+	for _, balance := range balances {
+		current[balance.Client] = balance.Balance
+	}
+
+	accounts := append([]*model.ClientAccount(nil), coreData.Accounts...)
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Name < accounts[j].Name
+	})
+
+	w := &errWriter{out: out}
+	for _, account := range accounts {
+		balance, ok := current[account.Name]
+		if !ok {
+			balance = account.Balance
+		}
+
+		w.printf("%s %d %d %d\n", account.Name, account.Balance, account.Balance-balance, balance)
+	}
+
+	return w.err
+}
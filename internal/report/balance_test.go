@@ -0,0 +1,27 @@
+package report
+
+import (
+	"bytes"
+	"yadro-intern/internal/model"
+	"yadro-intern/internal/processor"
+)
+
+func (s *reportSuite) TestWriteBalances() {
+	coreData := &model.CoreData{Accounts: []*model.ClientAccount{
+		model.NewClientAccount("client2", 50),
+		model.NewClientAccount("client1", 100),
+		model.NewClientAccount("client3", 20),
+	}}
+
+	balances := []processor.BalanceState{
+		{Client: "client1", Balance: 70},
+		{Client: "client2", Balance: 0},
+	}
+
+	var buf bytes.Buffer
+	s.Require().NoError(WriteBalances(&buf, coreData, balances))
+	s.Equal(`client1 100 30 70
+client2 50 50 0
+client3 20 0 20
+`, buf.String())
+}
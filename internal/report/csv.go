@@ -0,0 +1,111 @@
+package report
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+	"yadro-intern/internal/model"
+	"yadro-intern/internal/processor"
+)
+
+const totalRowName = "total"
+
+var csvHeader = []string{
+	"day",
+	"table",
+	"category",
+	"income",
+	"usage_minutes",
+	"sessions",
+	"first_occupied",
+	"last_released",
+}
+
+// WriteCSV writes the revenue and usage of every table of the club for every working day,
+// the rows of the day end with the totals of all tables. Days are numbered from 1.
+//
+// Unused tables are written with zero values and empty times.
+func WriteCSV(
+	out io.Writer,
+	coreData *model.CoreData,
+	days []*processor.DayResult,
+	timeFormat string,
+) error {
+	w := csv.NewWriter(out)
+	if err := w.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for i, result := range days {
+		day := strconv.Itoa(i + 1)
+
+		total := &model.RevenueStats{}
+		for table := 1; table <= coreData.TablesCount; table++ {
+			stats, ok := result.Revenue.Get(table)
+			if !ok {
+				stats = &model.RevenueStats{}
+			}
+
+			var categoryName string
+			if category := coreData.CategoryOf(table); category != nil {
+				categoryName = category.Name
+			}
+
+			if err := w.Write(csvRow(day, strconv.Itoa(table), categoryName, stats, timeFormat)); err != nil {
+				return err
+			}
+
+			addStats(total, stats)
+		}
+
+		if err := w.Write(csvRow(day, totalRowName, "", total, timeFormat)); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+func csvRow(day, table, category string, stats *model.RevenueStats, timeFormat string) []string {
+	return []string{
+		day,
+		table,
+		category,
+		strconv.Itoa(stats.Income),
+		strconv.Itoa(int(stats.UsageTime.Minutes())),
+		strconv.Itoa(stats.Sessions),
+		formatOptionalTime(stats.FirstOccupied, timeFormat),
+		formatOptionalTime(stats.LastReleased, timeFormat),
+	}
+}
+
+// addStats adds the table stats to the total ones,
+// keeping the earliest occupation and the latest release.
+func addStats(total, stats *model.RevenueStats) {
+	if stats.Sessions == 0 {
+		return
+	}
+
+	if total.Sessions == 0 || stats.FirstOccupied.Before(total.FirstOccupied) {
+		total.FirstOccupied = stats.FirstOccupied
+	}
+
+	if total.Sessions == 0 || stats.LastReleased.After(total.LastReleased) {
+		total.LastReleased = stats.LastReleased
+	}
+
+	total.Income += stats.Income
+	total.UsageTime += stats.UsageTime
+	total.Sessions += stats.Sessions
+}
+
+// formatOptionalTime formats the time, the zero time is written as an empty cell.
+func formatOptionalTime(t time.Time, timeFormat string) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(timeFormat)
+}
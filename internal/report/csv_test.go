@@ -0,0 +1,65 @@
+package report
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+	"yadro-intern/internal/model"
+	"yadro-intern/internal/processor"
+	"yadro-intern/internal/storage"
+)
+
+type reportSuite struct {
+	suite.Suite
+}
+
+func TestReportSuite(t *testing.T) {
+	suite.Run(t, new(reportSuite))
+}
+
+func (s *reportSuite) TestWriteCSV() {
+	coreData := model.NewCoreData(3, 10, model.NewTimeInterval(
+		time.Date(0, 0, 0, 9, 0, 0, 0, time.UTC),
+		time.Date(0, 0, 0, 19, 0, 0, 0, time.UTC),
+	))
+	coreData.Categories = []*model.TableCategory{model.NewTableCategory("vip", 30, []int{2})}
+
+	revenue := storage.NewInMemoryStorage[int, *model.RevenueStats]()
+	revenue.Set(1, &model.RevenueStats{
+		Income:        70,
+		UsageTime:     5*time.Hour + 58*time.Minute,
+		Sessions:      2,
+		FirstOccupied: time.Date(0, 0, 0, 9, 54, 0, 0, time.UTC),
+		LastReleased:  time.Date(0, 0, 0, 15, 52, 0, 0, time.UTC),
+	})
+	revenue.Set(2, &model.RevenueStats{
+		Income:        90,
+		UsageTime:     2*time.Hour + 18*time.Minute,
+		Sessions:      1,
+		FirstOccupied: time.Date(0, 0, 0, 10, 25, 0, 0, time.UTC),
+		LastReleased:  time.Date(0, 0, 0, 12, 43, 0, 0, time.UTC),
+	})
+
+	nextDay := storage.NewInMemoryStorage[int, *model.RevenueStats]()
+	nextDay.Set(3, &model.RevenueStats{
+		Income:        10,
+		UsageTime:     40 * time.Minute,
+		Sessions:      1,
+		FirstOccupied: time.Date(0, 0, 1, 11, 0, 0, 0, time.UTC),
+		LastReleased:  time.Date(0, 0, 1, 11, 40, 0, 0, time.UTC),
+	})
+
+	var buf bytes.Buffer
+	s.NoError(WriteCSV(&buf, coreData, []*processor.DayResult{{Revenue: revenue}, {Revenue: nextDay}}, "15:04"))
+	s.Equal(`day,table,category,income,usage_minutes,sessions,first_occupied,last_released
+1,1,,70,358,2,09:54,15:52
+1,2,vip,90,138,1,10:25,12:43
+1,3,,0,0,0,,
+1,total,,160,496,3,09:54,15:52
+2,1,,0,0,0,,
+2,2,vip,0,0,0,,
+2,3,,10,40,1,11:00,11:40
+2,total,,10,40,1,11:00,11:40
+`, buf.String())
+}
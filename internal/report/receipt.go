@@ -0,0 +1,83 @@
+package report
+
+import (
+	"io"
+	"sort"
+	"time"
+	"yadro-intern/internal/model"
+	"yadro-intern/internal/processor"
+)
+
+// WriteReceiptsDays writes the receipts of every working day by WriteReceipts, the days are separated by the "day" line.
+func WriteReceiptsDays(out io.Writer, days []*processor.DayResult, timeFormat string) error {
+	w := &errWriter{out: out}
+	for i, day := range days {
+		if i > 0 {
+			w.printf("\n%s\n\n", daySeparator)
+		}
+
+		if w.err == nil {
+			w.err = WriteReceipts(out, day.Sessions, timeFormat)
+		}
+	}
+
+	return w.err
+}
+
+// WriteReceipts writes the itemized receipt of every client ordered by the name:
+// the client name, a line for every session at the table and the total.
+//
+//	client1
+//	1 09:54 12:33 02:39 03:00 30
+//	total 02:39 03:00 30
+//
+// The session line is the table, the time the client sat down and left the table,
+// the usage time, the billed time and the amount. Receipts are separated by an empty line.
+func WriteReceipts(out io.Writer, sessions []*model.Session, timeFormat string) error {
+	byClient := make(map[string][]*model.Session)
+	for _, session := range sessions {
+		byClient[session.Client] = append(byClient[session.Client], session)
+	}
+
+	clients := make([]string, 0, len(byClient))
+	for client := range byClient {
+		clients = append(clients, client)
+	}
+
+	sort.Strings(clients)
+
+	w := &errWriter{out: out}
+	for i, client := range clients {
+		if i > 0 {
+			w.printf("\n")
+		}
+
+		w.printf("%s\n", client)
+
+		var (
+			usage, billed time.Duration
+			amount        int
+		)
+
+		// sessions are sorted by the end, so a client's sessions are in the chronological order
+		for _, session := range byClient[client] {
+			w.printf(
+				"%d %s %s %s %s %d\n",
+				session.Table, session.From.Format(timeFormat), session.To.Format(timeFormat),
+				model.FormatDuration(session.UsageTime(), timeFormat), model.FormatDuration(session.Billed, timeFormat),
+				session.Amount,
+			)
+
+			usage += session.UsageTime()
+			billed += session.Billed
+			amount += session.Amount
+		}
+
+		w.printf(
+			"total %s %s %d\n",
+			model.FormatDuration(usage, timeFormat), model.FormatDuration(billed, timeFormat), amount,
+		)
+	}
+
+	return w.err
+}
@@ -0,0 +1,53 @@
+package report
+
+import (
+	"bytes"
+	"time"
+	"yadro-intern/internal/model"
+	"yadro-intern/internal/processor"
+)
+
+func (s *reportSuite) TestWriteReceipts() {
+	at := func(hour, minute, second int) time.Time {
+		return time.Date(0, 0, 0, hour, minute, second, 0, time.UTC)
+	}
+
+	sessions := []*model.Session{
+		model.NewSession("client2", 1, at(10, 0, 0), at(10, 20, 0), time.Hour, 10),
+		model.NewSession("client1", 2, at(10, 5, 0), at(11, 35, 30), 2*time.Hour, 20),
+		model.NewSession("client2", 3, at(10, 20, 0), at(12, 0, 0), 2*time.Hour, 30),
+	}
+
+	var buf bytes.Buffer
+	s.Require().NoError(WriteReceipts(&buf, sessions, "15:04"))
+	s.Equal(`client1
+2 10:05 11:35 01:30 02:00 20
+total 01:30 02:00 20
+
+client2
+1 10:00 10:20 00:20 01:00 10
+3 10:20 12:00 01:40 02:00 30
+total 02:00 03:00 40
+`, buf.String())
+
+	buf.Reset()
+	days := []*processor.DayResult{{Sessions: sessions[:1]}, {Sessions: sessions[1:2]}}
+	s.Require().NoError(WriteReceiptsDays(&buf, days, "15:04"))
+	s.Equal(`client2
+1 10:00 10:20 00:20 01:00 10
+total 00:20 01:00 10
+
+day
+
+client1
+2 10:05 11:35 01:30 02:00 20
+total 01:30 02:00 20
+`, buf.String())
+
+	buf.Reset()
+	s.Require().NoError(WriteReceipts(&buf, sessions[1:2], "15:04:05"))
+	s.Equal(`client1
+2 10:05:00 11:35:30 01:30:30 02:00:00 20
+total 01:30:30 02:00:00 20
+`, buf.String())
+}
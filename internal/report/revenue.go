@@ -0,0 +1,149 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"yadro-intern/internal/model"
+	"yadro-intern/internal/processor"
+	"yadro-intern/internal/storage"
+)
+
+// daySeparator separates the sections of the working days in the text reports, as in the input.
+const daySeparator = "day"
+
+// WriteTextDays writes the revenue of every working day by WriteText, the days are separated by the "day" line.
+func WriteTextDays(out io.Writer, coreData *model.CoreData, days []*processor.DayResult, timeFormat string) error {
+	for i, day := range days {
+		if i > 0 {
+			if _, err := fmt.Fprintf(out, "%s\n", daySeparator); err != nil {
+				return err
+			}
+		}
+
+		if err := WriteText(out, coreData, day.Revenue, timeFormat); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteText writes the revenue of the used tables and of the table categories
+// in the same lines as the end of the processing results.
+func WriteText(
+	out io.Writer,
+	coreData *model.CoreData,
+	revenue storage.Storage[int, *model.RevenueStats],
+	timeFormat string,
+) error {
+	for table := 1; table <= coreData.TablesCount; table++ {
+		stats, ok := revenue.Get(table)
+		if !ok {
+			continue
+		}
+
+		if _, err := fmt.Fprintf(out, "%d %s\n", table, stats.Format(timeFormat)); err != nil {
+			return err
+		}
+	}
+
+	for _, category := range coreData.Categories {
+		stats := categoryStats(category, revenue)
+		if _, err := fmt.Fprintf(out, "%s %s\n", category.Name, stats.Format(timeFormat)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type jsonRevenueReport struct {
+	Revenue    []jsonTableRevenue `json:"revenue"`
+	Categories []jsonTableRevenue `json:"categories,omitempty"`
+	Total      jsonTableRevenue   `json:"total"`
+}
+
+type jsonTableRevenue struct {
+	Table         int    `json:"table,omitempty"`
+	Category      string `json:"category,omitempty"`
+	Income        int    `json:"income"`
+	UsageTime     string `json:"usage_time"`
+	UsageMinutes  int    `json:"usage_minutes"`
+	Sessions      int    `json:"sessions"`
+	FirstOccupied string `json:"first_occupied,omitempty"`
+	LastReleased  string `json:"last_released,omitempty"`
+}
+
+// WriteJSON writes the revenue and usage of every table, table categories and their totals.
+func WriteJSON(
+	out io.Writer,
+	coreData *model.CoreData,
+	revenue storage.Storage[int, *model.RevenueStats],
+	timeFormat string,
+) error {
+	report := jsonRevenueReport{Revenue: make([]jsonTableRevenue, 0, coreData.TablesCount)}
+
+	total := &model.RevenueStats{}
+	for table := 1; table <= coreData.TablesCount; table++ {
+		stats, ok := revenue.Get(table)
+		if !ok {
+			stats = &model.RevenueStats{}
+		}
+
+		row := newJSONTableRevenue(stats, timeFormat)
+		row.Table = table
+		if category := coreData.CategoryOf(table); category != nil {
+			row.Category = category.Name
+		}
+
+		report.Revenue = append(report.Revenue, row)
+		addStats(total, stats)
+	}
+
+	for _, category := range coreData.Categories {
+		row := newJSONTableRevenue(categoryStats(category, revenue), timeFormat)
+		row.Category = category.Name
+		report.Categories = append(report.Categories, row)
+	}
+
+	report.Total = newJSONTableRevenue(total, timeFormat)
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// WriteJSONDays writes the revenue of every working day by WriteJSON as a separate JSON document.
+func WriteJSONDays(out io.Writer, coreData *model.CoreData, days []*processor.DayResult, timeFormat string) error {
+	for _, day := range days {
+		if err := WriteJSON(out, coreData, day.Revenue, timeFormat); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newJSONTableRevenue(stats *model.RevenueStats, timeFormat string) jsonTableRevenue {
+	return jsonTableRevenue{
+		Income:        stats.Income,
+		UsageTime:     stats.UsageClock(timeFormat),
+		UsageMinutes:  int(stats.UsageTime.Minutes()),
+		Sessions:      stats.Sessions,
+		FirstOccupied: formatOptionalTime(stats.FirstOccupied, timeFormat),
+		LastReleased:  formatOptionalTime(stats.LastReleased, timeFormat),
+	}
+}
+
+// categoryStats sums up the stats of all tables of the category.
+func categoryStats(category *model.TableCategory, revenue storage.Storage[int, *model.RevenueStats]) *model.RevenueStats {
+	total := &model.RevenueStats{}
+	for _, table := range category.Tables {
+		if stats, ok := revenue.Get(table); ok {
+			addStats(total, stats)
+		}
+	}
+
+	return total
+}
@@ -0,0 +1,141 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+	"yadro-intern/internal/processor"
+)
+
+// WriteStateText writes the state of the club at the moment in sections:
+// busy tables with their clients and the time they sat down, the waiting queue,
+// clients in the club with their tables, the revenue of the used tables and the reservations.
+func WriteStateText(out io.Writer, state *processor.State, at time.Time, timeFormat string) error {
+	w := &errWriter{out: out}
+
+	w.printf("%s\n", at.Format(timeFormat))
+
+	w.printf("tables:\n")
+	for _, table := range state.Tables {
+		w.printf("%d %s %s\n", table.Table, table.Client, table.Since.Format(timeFormat))
+	}
+
+	w.printf("queue:\n")
+	for _, client := range state.Queue {
+		w.printf("%s\n", client)
+	}
+
+	w.printf("clients:\n")
+	for _, client := range state.Clients {
+		if client.Table == 0 {
+			w.printf("%s\n", client.Name)
+			continue
+		}
+
+		w.printf("%s %d\n", client.Name, client.Table)
+	}
+
+	w.printf("revenue:\n")
+	for _, revenue := range state.Revenue {
+		w.printf("%d %s\n", revenue.Table, revenue.Stats.Format(timeFormat))
+	}
+
+	w.printf("reservations:\n")
+	for _, reservation := range state.Reservations {
+		w.printf(
+			"%s %d %s %s\n",
+			reservation.Client, reservation.Table,
+			reservation.From.Format(timeFormat), reservation.To.Format(timeFormat),
+		)
+	}
+
+	return w.err
+}
+
+type jsonState struct {
+	Time    string             `json:"time"`
+	Tables  []jsonTableState   `json:"tables"`
+	Queue   []string           `json:"queue"`
+	Clients []jsonClientState  `json:"clients"`
+	Revenue []jsonTableRevenue `json:"revenue"`
+
+	Reservations []jsonReservationState `json:"reservations"`
+}
+
+type jsonTableState struct {
+	Table  int    `json:"table"`
+	Client string `json:"client"`
+	Since  string `json:"since"`
+}
+
+type jsonClientState struct {
+	Name  string `json:"name"`
+	Table int    `json:"table,omitempty"`
+}
+
+type jsonReservationState struct {
+	Client string `json:"client"`
+	Table  int    `json:"table"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+}
+
+// WriteStateJSON writes the state of the club at the moment as a JSON document.
+func WriteStateJSON(out io.Writer, state *processor.State, at time.Time, timeFormat string) error {
+	report := jsonState{
+		Time:    at.Format(timeFormat),
+		Tables:  make([]jsonTableState, 0, len(state.Tables)),
+		Queue:   state.Queue,
+		Clients: make([]jsonClientState, 0, len(state.Clients)),
+		Revenue: make([]jsonTableRevenue, 0, len(state.Revenue)),
+
+		Reservations: make([]jsonReservationState, 0, len(state.Reservations)),
+	}
+
+	for _, table := range state.Tables {
+		report.Tables = append(report.Tables, jsonTableState{
+			Table:  table.Table,
+			Client: table.Client,
+			Since:  table.Since.Format(timeFormat),
+		})
+	}
+
+	for _, client := range state.Clients {
+		report.Clients = append(report.Clients, jsonClientState{Name: client.Name, Table: client.Table})
+	}
+
+	for _, revenue := range state.Revenue {
+		stats := revenue.Stats
+		row := newJSONTableRevenue(&stats, timeFormat)
+		row.Table = revenue.Table
+		report.Revenue = append(report.Revenue, row)
+	}
+
+	for _, reservation := range state.Reservations {
+		report.Reservations = append(report.Reservations, jsonReservationState{
+			Client: reservation.Client,
+			Table:  reservation.Table,
+			From:   reservation.From.Format(timeFormat),
+			To:     reservation.To.Format(timeFormat),
+		})
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// errWriter keeps the first write error, so the following writes can skip checking it.
+type errWriter struct {
+	out io.Writer
+	err error
+}
+
+func (w *errWriter) printf(format string, args ...any) {
+	if w.err != nil {
+		return
+	}
+
+	_, w.err = fmt.Fprintf(w.out, format, args...)
+}
@@ -0,0 +1,54 @@
+package report
+
+import (
+	"bytes"
+	"time"
+	"yadro-intern/internal/model"
+	"yadro-intern/internal/processor"
+)
+
+func (s *reportSuite) TestWriteState() {
+	at := func(hour, minute int) time.Time {
+		return time.Date(0, 0, 0, hour, minute, 0, 0, time.UTC)
+	}
+
+	state := &processor.State{
+		Tables:  []processor.TableState{{Table: 1, Client: "client1", Since: at(10, 0)}},
+		Queue:   []string{"client3"},
+		Clients: []processor.ClientState{{Name: "client1", Table: 1}, {Name: "client3"}},
+		Revenue: []processor.TableRevenue{{Table: 1, Stats: model.RevenueStats{
+			Income:        20,
+			UsageTime:     90 * time.Minute,
+			Sessions:      2,
+			FirstOccupied: at(9, 0),
+			LastReleased:  at(9, 30),
+		}}},
+
+		Reservations: []processor.ReservationState{{Client: "client2", Table: 2, From: at(12, 0), To: at(13, 30)}},
+	}
+
+	var out bytes.Buffer
+	s.Require().NoError(WriteStateText(&out, state, at(11, 0), "15:04"))
+	s.Require().Equal("11:00\ntables:\n1 client1 10:00\nqueue:\nclient3\n"+
+		"clients:\nclient1 1\nclient3\nrevenue:\n1 20 01:30\n"+
+		"reservations:\nclient2 2 12:00 13:30\n", out.String())
+
+	out.Reset()
+	s.Require().NoError(WriteStateJSON(&out, state, at(11, 0), "15:04"))
+	s.Require().JSONEq(`{
+		"time": "11:00",
+		"tables": [{"table": 1, "client": "client1", "since": "10:00"}],
+		"queue": ["client3"],
+		"clients": [{"name": "client1", "table": 1}, {"name": "client3"}],
+		"revenue": [{
+			"table": 1,
+			"income": 20,
+			"usage_time": "01:30",
+			"usage_minutes": 90,
+			"sessions": 2,
+			"first_occupied": "09:00",
+			"last_released": "09:30"
+		}],
+		"reservations": [{"client": "client2", "table": 2, "from": "12:00", "to": "13:30"}]
+	}`, out.String())
+}
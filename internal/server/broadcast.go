@@ -0,0 +1,93 @@
+package server
+
+import (
+	"sync"
+	"yadro-intern/internal/ifces"
+)
+
+// subscriberBufferSize is the count of events kept for the subscriber,
+// which doesn't read them yet.
+const subscriberBufferSize = 64
+
+// broadcast delivers written events to any number of subscribers.
+//
+// Publishing never blocks: a subscriber with the full buffer is too slow,
+// so it's disconnected by closing its channel.
+type broadcast struct {
+	mu          sync.Mutex
+	bufferSize  int
+	subscribers map[chan ifces.TimeFormatter]struct{}
+	closed      bool
+}
+
+func newBroadcast(bufferSize int) *broadcast {
+	return &broadcast{
+		bufferSize:  bufferSize,
+		subscribers: make(map[chan ifces.TimeFormatter]struct{}),
+	}
+}
+
+// subscribe returns the channel of the events published after the call,
+// the channel is closed when the subscriber is disconnected.
+//
+// ok is false, if the broadcast is closed.
+func (b *broadcast) subscribe() (events chan ifces.TimeFormatter, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, false
+	}
+
+	events = make(chan ifces.TimeFormatter, b.bufferSize)
+	b.subscribers[events] = struct{}{}
+	return events, true
+}
+
+func (b *broadcast) unsubscribe(events chan ifces.TimeFormatter) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.remove(events)
+}
+
+func (b *broadcast) publish(event ifces.TimeFormatter) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for events := range b.subscribers {
+		select {
+		case events <- event:
+		default:
+			b.remove(events)
+		}
+	}
+}
+
+// close disconnects all subscribers after they read the published events.
+func (b *broadcast) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for events := range b.subscribers {
+		b.remove(events)
+	}
+
+	b.closed = true
+}
+
+func (b *broadcast) remove(events chan ifces.TimeFormatter) {
+	if _, ok := b.subscribers[events]; !ok {
+		return
+	}
+
+	delete(b.subscribers, events)
+	close(events)
+}
+
+func (b *broadcast) len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.subscribers)
+}
@@ -0,0 +1,428 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"yadro-intern/internal/apierror"
+	"yadro-intern/internal/ifces"
+	"yadro-intern/internal/journal"
+	"yadro-intern/internal/model"
+	"yadro-intern/internal/output"
+	"yadro-intern/internal/processor"
+	"yadro-intern/internal/report"
+)
+
+// ErrDayClosed is returned, when the working day is already closed.
+var ErrDayClosed = errors.New("working day is closed")
+
+// InvalidEventError means, that the event can't be applied because of its content.
+type InvalidEventError struct {
+	UserMsg string
+}
+
+func (e *InvalidEventError) Error() string {
+	return fmt.Sprintf("invalid event: %s", e.UserMsg)
+}
+
+// Server is the live session of the computer club,
+// events received by HTTP are applied to the single processor.
+//
+// Requests are serialized, because the processor and its storages
+// aren't safe for concurrent use.
+type Server struct {
+	mu sync.Mutex
+
+	processor  *processor.EventProcessorImpl
+	coreData   *model.CoreData
+	timeFormat string
+
+	// inputTimeFormat is the format of the time in the requests.
+	inputTimeFormat string
+
+	// lastEvent is the latest applied event,
+	// events happened before it are rejected.
+	lastEvent *model.IncomingEvent
+
+	closed bool
+
+	// written collects events written by the processor while applying the event.
+	written []ifces.TimeFormatter
+
+	// stream delivers written events to the stream subscribers.
+	stream *broadcast
+
+	// recorder keeps the applied events, so the session can be restored, nil if it isn't used.
+	recorder *journal.Recorder
+}
+
+// New starts the working day of the processor.
+//
+// Time in the requests is parsed by inputTimeFormat, time in the responses is formatted by timeFormat.
+func New(
+	p *processor.EventProcessorImpl,
+	coreData *model.CoreData,
+	inputTimeFormat, timeFormat string,
+) *Server {
+	s := &Server{
+		processor:       p,
+		coreData:        coreData,
+		timeFormat:      timeFormat,
+		inputTimeFormat: inputTimeFormat,
+		stream:          newBroadcast(subscriberBufferSize),
+	}
+
+	p.AddListener(func(event ifces.TimeFormatter) {
+		s.written = append(s.written, event)
+		s.stream.publish(event)
+	})
+
+	p.Start()
+	return s
+}
+
+// Record makes the session keep the applied events by the recorder,
+// restored is the state the processor is restored to by the recorder.
+func (s *Server) Record(recorder *journal.Recorder, restored *journal.Restored) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.recorder = recorder
+	s.lastEvent = restored.LastEvent
+	s.closed = restored.Closed
+	if s.closed {
+		s.stream.close()
+	}
+}
+
+// Apply applies the incoming event and returns all events written because of it:
+// the incoming event itself and the generated outgoing ones.
+func (s *Server) Apply(event *model.IncomingEvent) ([]ifces.TimeFormatter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, ErrDayClosed
+	}
+
+	if s.lastEvent != nil && event.HappensAt.Before(s.lastEvent.HappensAt) {
+		return nil, &InvalidEventError{UserMsg: apierror.ErrEventTimeBeforePrevious}
+	}
+
+	// the event is applied only after it's recorded, so it isn't lost after the restart
+	if s.recorder != nil {
+		if err := s.recorder.RecordIncoming(event); err != nil {
+			return nil, fmt.Errorf("failed to record event: %w", err)
+		}
+	}
+
+	s.written = nil
+	s.processor.Apply(event)
+	s.lastEvent = event
+
+	// the recorded event is applied by the replay anyway, the failure is returned for the next event
+	if s.recorder != nil {
+		_ = s.recorder.Checkpoint()
+	}
+
+	return s.written, nil
+}
+
+// Close finishes the working day: remaining clients leave and the revenue is written.
+func (s *Server) Close() ([]ifces.TimeFormatter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, ErrDayClosed
+	}
+
+	if s.recorder != nil {
+		if err := s.recorder.MarkClosed(); err != nil {
+			return nil, fmt.Errorf("failed to record closing: %w", err)
+		}
+	}
+
+	s.written = nil
+	s.processor.Finish()
+	s.processor.ShowRevenue()
+	s.closed = true
+	s.stream.close()
+
+	// the closing is recorded already and the day is finished by the replay anyway
+	if s.recorder != nil {
+		_ = s.recorder.Checkpoint()
+	}
+
+	return s.written, nil
+}
+
+// Handler returns the HTTP API of the session:
+//
+//	POST /events         applies the incoming event
+//	GET  /events/stream  streams the written events as Server-Sent Events
+//	GET  /state          returns the busy tables, the waiting queue and the clients in the club
+//	GET  /revenue        returns the running revenue of the tables
+//	POST /close          closes the working day
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", s.handleEvents)
+	mux.HandleFunc("/events/stream", s.handleStream)
+	mux.HandleFunc("/state", s.handleState)
+	mux.HandleFunc("/revenue", s.handleRevenue)
+	mux.HandleFunc("/close", s.handleClose)
+	return mux
+}
+
+type eventRequest struct {
+	Time   string `json:"time"`
+	Type   int    `json:"type"`
+	Client string `json:"client"`
+	Table  int    `json:"table,omitempty"`
+
+	// From and To are the reserved time of the reservation.
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+}
+
+type eventsResponse struct {
+	Events []output.JSONEvent `json:"events"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	var req eventRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %s", err))
+		return
+	}
+
+	event, err := s.newEvent(&req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	written, err := s.Apply(event)
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.newEventsResponse(written))
+}
+
+func (s *Server) handleClose(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	written, err := s.Close()
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.newEventsResponse(written))
+}
+
+type stateResponse struct {
+	Closed  bool          `json:"closed"`
+	Tables  []tableState  `json:"tables"`
+	Queue   []string      `json:"queue"`
+	Clients []clientState `json:"clients"`
+
+	Reservations []reservationState `json:"reservations"`
+}
+
+type tableState struct {
+	Table  int    `json:"table"`
+	Client string `json:"client"`
+	Since  string `json:"since"`
+}
+
+type clientState struct {
+	Name  string `json:"name"`
+	Table int    `json:"table,omitempty"`
+}
+
+type reservationState struct {
+	Client string `json:"client"`
+	Table  int    `json:"table"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+}
+
+func (s *Server) handleState(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	s.mu.Lock()
+	state := s.processor.State()
+	closed := s.closed
+	s.mu.Unlock()
+
+	resp := stateResponse{
+		Closed:  closed,
+		Tables:  make([]tableState, 0, len(state.Tables)),
+		Queue:   state.Queue,
+		Clients: make([]clientState, 0, len(state.Clients)),
+
+		Reservations: make([]reservationState, 0, len(state.Reservations)),
+	}
+
+	for _, table := range state.Tables {
+		resp.Tables = append(resp.Tables, tableState{
+			Table:  table.Table,
+			Client: table.Client,
+			Since:  table.Since.Format(s.timeFormat),
+		})
+	}
+
+	for _, client := range state.Clients {
+		resp.Clients = append(resp.Clients, clientState{Name: client.Name, Table: client.Table})
+	}
+
+	for _, reservation := range state.Reservations {
+		resp.Reservations = append(resp.Reservations, reservationState{
+			Client: reservation.Client,
+			Table:  reservation.Table,
+			From:   reservation.From.Format(s.timeFormat),
+			To:     reservation.To.Format(s.timeFormat),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleRevenue(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	var buf bytes.Buffer
+
+	s.mu.Lock()
+	// the sessions in progress are counted up to the latest event
+	at := s.coreData.WorkingTime.Start
+	if s.lastEvent != nil {
+		at = s.lastEvent.HappensAt
+	}
+
+	err := report.WriteJSON(&buf, s.coreData, s.processor.RevenueAt(at), s.timeFormat)
+	s.mu.Unlock()
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = buf.WriteTo(w)
+}
+
+// newEvent validates the request in the same way as the parser validates the input rows.
+func (s *Server) newEvent(req *eventRequest) (*model.IncomingEvent, error) {
+	happensAt, err := s.coreData.WorkingTime.ParseTime(s.inputTimeFormat, req.Time)
+	if err != nil {
+		return nil, &InvalidEventError{UserMsg: apierror.ErrFailedToParseEventTime}
+	}
+
+	var slot *model.TimeInterval
+	if model.IncomingEventType(req.Type) == model.Reserves {
+		if slot, err = s.newSlot(req.From, req.To); err != nil {
+			return nil, err
+		}
+	}
+
+	client, ok := model.NewClientData(
+		model.IncomingEventType(req.Type), req.Client, req.Table, s.coreData.TablesCount, slot,
+	)
+	if !ok {
+		return nil, &InvalidEventError{UserMsg: apierror.ErrUnknownEventType}
+	}
+
+	if err = client.Validate(); err != nil {
+		return nil, &InvalidEventError{UserMsg: err.Error()}
+	}
+
+	return model.NewIncomingEvent(happensAt, model.IncomingEventType(req.Type), client), nil
+}
+
+// newSlot parses the reserved time, the slot ending before the start lasts over midnight.
+func (s *Server) newSlot(fromStr, toStr string) (*model.TimeInterval, error) {
+	from, err := s.coreData.WorkingTime.ParseTime(s.inputTimeFormat, fromStr)
+	if err != nil {
+		return nil, &InvalidEventError{UserMsg: apierror.ErrFailedToParseReservationTime}
+	}
+
+	to, err := s.coreData.WorkingTime.ParseTime(s.inputTimeFormat, toStr)
+	if err != nil {
+		return nil, &InvalidEventError{UserMsg: apierror.ErrFailedToParseReservationTime}
+	}
+
+	return model.NewTimeInterval(from, to), nil
+}
+
+func (s *Server) newEventsResponse(written []ifces.TimeFormatter) eventsResponse {
+	resp := eventsResponse{Events: make([]output.JSONEvent, 0, len(written))}
+	for _, event := range written {
+		resp.Events = append(resp.Events, s.newJSONEvent(event))
+	}
+
+	return resp
+}
+
+func (s *Server) newJSONEvent(event ifces.TimeFormatter) output.JSONEvent {
+	switch e := event.(type) {
+	case *model.IncomingEvent:
+		return output.NewIncomingJSONEvent(e, s.timeFormat)
+	case *model.OutgoingEvent:
+		return output.NewOutgoingJSONEvent(e, s.timeFormat)
+	}
+
+	return output.JSONEvent{}
+}
+
+func statusOf(err error) int {
+	var invalidEvent *InvalidEventError
+	switch {
+	case errors.Is(err, ErrDayClosed):
+		return http.StatusConflict
+	case errors.As(err, &invalidEvent):
+		return http.StatusBadRequest
+	}
+
+	return http.StatusInternalServerError
+}
+
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+
+	w.Header().Set("Allow", method)
+	writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+	return false
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
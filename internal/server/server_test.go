@@ -0,0 +1,316 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"yadro-intern/cmd/config"
+	"yadro-intern/internal/billing"
+	"yadro-intern/internal/journal"
+	"yadro-intern/internal/model"
+	"yadro-intern/internal/output"
+	"yadro-intern/internal/processor"
+	"yadro-intern/internal/storage"
+)
+
+type serverSuite struct {
+	suite.Suite
+
+	out     *bytes.Buffer
+	session *Server
+	server  *httptest.Server
+}
+
+func TestServerSuite(t *testing.T) {
+	suite.Run(t, new(serverSuite))
+}
+
+func (s *serverSuite) SetupTest() {
+	cfg, err := config.NewProcessorConfig()
+	s.Require().NoError(err)
+
+	coreData := model.NewCoreData(2, 10, model.NewTimeInterval(
+		time.Date(0, 1, 1, 9, 0, 0, 0, time.UTC),
+		time.Date(0, 1, 1, 19, 0, 0, 0, time.UTC),
+	))
+
+	s.out = &bytes.Buffer{}
+	p := processor.NewEventProcessor(
+		s.out,
+		cfg,
+		coreData,
+		billing.NewHourly(),
+		storage.NewInMemoryStorage[int, *model.IncomingEvent](),
+		storage.NewInMemoryStorage[int, *model.RevenueStats](),
+		storage.NewInMemoryStorage[string, int](),
+		storage.NewInMemoryQueue[model.ClientData](nil),
+	)
+
+	s.session = New(p, coreData, cfg.TimeFormat, cfg.TimeFormat)
+	s.server = httptest.NewServer(s.session.Handler())
+}
+
+func (s *serverSuite) streamSubscribers() int {
+	return s.session.stream.len()
+}
+
+func (s *serverSuite) TearDownTest() {
+	s.server.Close()
+}
+
+func (s *serverSuite) do(method, path, body string) (int, string) {
+	req, err := http.NewRequest(method, s.server.URL+path, strings.NewReader(body))
+	s.Require().NoError(err)
+
+	resp, err := s.server.Client().Do(req)
+	s.Require().NoError(err)
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	var buf bytes.Buffer
+	_, err = buf.ReadFrom(resp.Body)
+	s.Require().NoError(err)
+
+	return resp.StatusCode, buf.String()
+}
+
+func (s *serverSuite) postEvent(body string) []output.JSONEvent {
+	status, resp := s.do(http.MethodPost, "/events", body)
+	s.Require().Equal(http.StatusOK, status, resp)
+
+	var events eventsResponse
+	s.Require().NoError(json.Unmarshal([]byte(resp), &events))
+	return events.Events
+}
+
+func (s *serverSuite) TestEvents() {
+	events := s.postEvent(`{"time":"08:48","type":1,"client":"client1"}`)
+	s.Require().Equal([]output.JSONEvent{
+		{Time: "08:48", Direction: "incoming", Type: 1, Client: "client1"},
+		{Time: "08:48", Direction: "outgoing", Type: 13, Error: "NotOpenYet"},
+	}, events)
+
+	s.postEvent(`{"time":"09:41","type":1,"client":"client1"}`)
+	events = s.postEvent(`{"time":"09:54","type":2,"client":"client1","table":1}`)
+	s.Require().Equal([]output.JSONEvent{
+		{Time: "09:54", Direction: "incoming", Type: 2, Client: "client1", Table: 1},
+	}, events)
+
+	s.Require().Equal("09:00\n08:48 1 client1\n08:48 13 NotOpenYet\n09:41 1 client1\n09:54 2 client1 1\n", s.out.String())
+}
+
+func (s *serverSuite) TestInvalidEvents() {
+	s.postEvent(`{"time":"10:00","type":1,"client":"client1"}`)
+
+	testCases := []struct {
+		name      string
+		method    string
+		body      string
+		expStatus int
+		expError  string
+	}{
+		{
+			name:      "invalid body",
+			method:    http.MethodPost,
+			body:      `{"time":`,
+			expStatus: http.StatusBadRequest,
+		},
+		{
+			name:      "invalid time",
+			method:    http.MethodPost,
+			body:      `{"time":"25:00","type":1,"client":"client2"}`,
+			expStatus: http.StatusBadRequest,
+			expError:  "invalid event: failed to parse event happened time",
+		},
+		{
+			name:      "unknown type",
+			method:    http.MethodPost,
+			body:      `{"time":"10:00","type":7,"client":"client2"}`,
+			expStatus: http.StatusBadRequest,
+			expError:  "invalid event: unknown event type",
+		},
+		{
+			name:      "table out of range",
+			method:    http.MethodPost,
+			body:      `{"time":"10:00","type":2,"client":"client1","table":3}`,
+			expStatus: http.StatusBadRequest,
+		},
+		{
+			name:      "before previous",
+			method:    http.MethodPost,
+			body:      `{"time":"09:59","type":1,"client":"client2"}`,
+			expStatus: http.StatusBadRequest,
+			expError:  "invalid event: event happened before the previous one",
+		},
+		{
+			name:      "wrong method",
+			method:    http.MethodGet,
+			expStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			status, body := s.do(tc.method, "/events", tc.body)
+			s.Require().Equal(tc.expStatus, status, body)
+
+			if tc.expError != "" {
+				var resp errorResponse
+				s.Require().NoError(json.Unmarshal([]byte(body), &resp))
+				s.Require().Equal(tc.expError, resp.Error)
+			}
+		})
+	}
+
+	// rejected events don't change the state
+	_, body := s.do(http.MethodGet, "/state", "")
+	s.Require().JSONEq(`{"closed":false,"tables":[],"queue":[],"clients":[{"name":"client1"}],"reservations":[]}`, body)
+}
+
+func (s *serverSuite) TestState() {
+	s.postEvent(`{"time":"09:41","type":1,"client":"client1"}`)
+	s.postEvent(`{"time":"09:42","type":1,"client":"client2"}`)
+	s.postEvent(`{"time":"09:43","type":1,"client":"client3"}`)
+	s.postEvent(`{"time":"09:54","type":2,"client":"client1","table":1}`)
+	s.postEvent(`{"time":"09:55","type":2,"client":"client2","table":2}`)
+	s.postEvent(`{"time":"09:56","type":3,"client":"client3"}`)
+
+	status, body := s.do(http.MethodGet, "/state", "")
+	s.Require().Equal(http.StatusOK, status)
+	s.Require().JSONEq(`{
+		"closed": false,
+		"tables": [
+			{"table": 1, "client": "client1", "since": "09:54"},
+			{"table": 2, "client": "client2", "since": "09:55"}
+		],
+		"queue": ["client3"],
+		"clients": [
+			{"name": "client1", "table": 1},
+			{"name": "client2", "table": 2},
+			{"name": "client3"}
+		],
+		"reservations": []
+	}`, body)
+}
+
+func (s *serverSuite) TestReservation() {
+	s.postEvent(`{"time":"09:41","type":5,"client":"client1","table":2,"from":"12:00","to":"13:30"}`)
+
+	status, body := s.do(http.MethodPost, "/events", `{"time":"09:42","type":5,"client":"client2","table":2,"from":"12:00"}`)
+	s.Require().Equal(http.StatusBadRequest, status, body)
+	s.Require().JSONEq(`{"error":"invalid event: failed to parse reservation time"}`, body)
+
+	status, body = s.do(http.MethodGet, "/state", "")
+	s.Require().Equal(http.StatusOK, status)
+	s.Require().JSONEq(`{
+		"closed": false,
+		"tables": [],
+		"queue": [],
+		"clients": [],
+		"reservations": [{"client": "client1", "table": 2, "from": "12:00", "to": "13:30"}]
+	}`, body)
+}
+
+func (s *serverSuite) TestRecordFailure() {
+	recorder, restored, err := journal.Open(s.T().TempDir(), s.session.processor, 2, 0)
+	s.Require().NoError(err)
+	s.session.Record(recorder, restored)
+
+	s.postEvent(`{"time":"09:41","type":1,"client":"client1"}`)
+
+	// the event, which can't be recorded, isn't applied
+	s.Require().NoError(recorder.Close())
+	status, _ := s.do(http.MethodPost, "/events", `{"time":"09:42","type":1,"client":"client2"}`)
+	s.Require().Equal(http.StatusInternalServerError, status)
+
+	status, body := s.do(http.MethodGet, "/state", "")
+	s.Require().Equal(http.StatusOK, status)
+	s.Require().JSONEq(`{
+		"closed": false,
+		"tables": [],
+		"queue": [],
+		"clients": [{"name": "client1"}],
+		"reservations": []
+	}`, body)
+	s.Require().Equal("09:00\n09:41 1 client1\n", s.out.String())
+}
+
+func (s *serverSuite) TestRevenueInProgress() {
+	s.postEvent(`{"time":"09:41","type":1,"client":"client1"}`)
+	s.postEvent(`{"time":"09:54","type":2,"client":"client1","table":1}`)
+	s.postEvent(`{"time":"11:30","type":1,"client":"client2"}`)
+
+	// the session in progress is counted up to the latest event
+	status, body := s.do(http.MethodGet, "/revenue", "")
+	s.Require().Equal(http.StatusOK, status)
+
+	var revenue struct {
+		Revenue []struct {
+			Table  int    `json:"table"`
+			Income int    `json:"income"`
+			Usage  string `json:"usage_time"`
+		} `json:"revenue"`
+	}
+	s.Require().NoError(json.Unmarshal([]byte(body), &revenue), body)
+	s.Require().Len(revenue.Revenue, 2)
+	s.Require().Equal(1, revenue.Revenue[0].Table)
+	s.Require().Equal(20, revenue.Revenue[0].Income)
+	s.Require().Equal("01:36", revenue.Revenue[0].Usage)
+
+	// the running revenue doesn't end the session
+	_, body = s.do(http.MethodGet, "/state", "")
+	s.Require().Contains(body, `{"table":1,"client":"client1","since":"09:54"}`)
+}
+
+func (s *serverSuite) TestClose() {
+	s.postEvent(`{"time":"09:41","type":1,"client":"client1"}`)
+	s.postEvent(`{"time":"09:54","type":2,"client":"client1","table":1}`)
+	s.postEvent(`{"time":"12:33","type":4,"client":"client1"}`)
+	s.postEvent(`{"time":"12:40","type":1,"client":"client2"}`)
+
+	status, body := s.do(http.MethodGet, "/revenue", "")
+	s.Require().Equal(http.StatusOK, status)
+	s.Require().Contains(body, `"income": 30`)
+
+	status, body = s.do(http.MethodPost, "/close", "")
+	s.Require().Equal(http.StatusOK, status)
+	s.Require().JSONEq(`{"events":[{"time":"19:00","direction":"outgoing","type":11,"client":"client2"}]}`, body)
+	s.Require().True(strings.HasSuffix(s.out.String(), "19:00 11 client2\n19:00\n1 30 02:39\n"), s.out.String())
+
+	status, _ = s.do(http.MethodPost, "/close", "")
+	s.Require().Equal(http.StatusConflict, status)
+
+	status, _ = s.do(http.MethodPost, "/events", `{"time":"19:30","type":1,"client":"client3"}`)
+	s.Require().Equal(http.StatusConflict, status)
+}
+
+func (s *serverSuite) TestConcurrentRequests() {
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+
+		go func(i int) {
+			defer wg.Done()
+			s.do(http.MethodPost, "/events", `{"time":"10:00","type":1,"client":"client`+string(rune('a'+i))+`"}`)
+		}(i)
+
+		go func() {
+			defer wg.Done()
+			s.do(http.MethodGet, "/state", "")
+		}()
+	}
+
+	wg.Wait()
+
+	_, body := s.do(http.MethodGet, "/state", "")
+	var state stateResponse
+	s.Require().NoError(json.Unmarshal([]byte(body), &state))
+	s.Require().Len(state.Clients, 20)
+}
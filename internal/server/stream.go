@@ -0,0 +1,98 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"yadro-intern/internal/ifces"
+	"yadro-intern/internal/model"
+	"yadro-intern/internal/output"
+)
+
+// names of the Server-Sent Events, so clients can listen only to the generated events
+const (
+	streamEventIncoming = "incoming"
+	streamEventOutgoing = "outgoing"
+)
+
+// handleStream sends every written event as the Server-Sent Event,
+// its data is the text line or the JSON object, if the format query parameter is "json".
+//
+// The stream ends, when the working day is closed,
+// or when the client doesn't keep up with the events.
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = output.FormatText
+	}
+
+	if !output.IsKnownFormat(format) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown format: %s", format))
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming is not supported"))
+		return
+	}
+
+	events, ok := s.stream.subscribe()
+	if !ok {
+		writeError(w, http.StatusConflict, ErrDayClosed)
+		return
+	}
+
+	defer s.stream.unsubscribe(events)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	// comment line makes the client know, that the subscription is ready
+	_, _ = fmt.Fprint(w, ": subscribed\n\n")
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+
+			data, err := s.streamData(event, format)
+			if err != nil {
+				return
+			}
+
+			if _, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", streamEventName(event), data); err != nil {
+				return
+			}
+
+			flusher.Flush()
+		}
+	}
+}
+
+func (s *Server) streamData(event ifces.TimeFormatter, format string) (string, error) {
+	if format == output.FormatText {
+		return event.String(s.timeFormat), nil
+	}
+
+	data, err := json.Marshal(s.newJSONEvent(event))
+	return string(data), err
+}
+
+func streamEventName(event ifces.TimeFormatter) string {
+	if _, ok := event.(*model.OutgoingEvent); ok {
+		return streamEventOutgoing
+	}
+
+	return streamEventIncoming
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"yadro-intern/internal/model"
+)
+
+// openStream subscribes to the stream and waits until the subscription is ready.
+func (s *serverSuite) openStream(query string) (*bufio.Reader, func()) {
+	resp, err := s.server.Client().Get(s.server.URL + "/events/stream" + query)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	s.Require().Equal("text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	s.Require().NoError(err)
+	s.Require().Equal(": subscribed\n", line)
+
+	_, err = reader.ReadString('\n')
+	s.Require().NoError(err)
+
+	return reader, func() {
+		_ = resp.Body.Close()
+	}
+}
+
+// readStreamEvent reads the next Server-Sent Event, io.EOF means the end of the stream.
+func (s *serverSuite) readStreamEvent(reader *bufio.Reader) (name, data string, err error) {
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return "", "", err
+		}
+
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			return name, data, nil
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func (s *serverSuite) requireStreamEvents(reader *bufio.Reader, expected ...string) {
+	for _, exp := range expected {
+		name, data, err := s.readStreamEvent(reader)
+		s.Require().NoError(err)
+		s.Require().Equal(exp, name+" "+data)
+	}
+}
+
+func (s *serverSuite) TestStream() {
+	text, closeText := s.openStream("")
+	defer closeText()
+
+	jsonStream, closeJSON := s.openStream("?format=json")
+	defer closeJSON()
+
+	s.postEvent(`{"time":"08:48","type":1,"client":"client1"}`)
+	s.postEvent(`{"time":"09:41","type":1,"client":"client1"}`)
+
+	s.requireStreamEvents(text,
+		"incoming 08:48 1 client1",
+		"outgoing 08:48 13 NotOpenYet",
+		"incoming 09:41 1 client1",
+	)
+
+	s.requireStreamEvents(jsonStream,
+		`incoming {"time":"08:48","direction":"incoming","type":1,"client":"client1"}`,
+		`outgoing {"time":"08:48","direction":"outgoing","type":13,"error":"NotOpenYet"}`,
+		`incoming {"time":"09:41","direction":"incoming","type":1,"client":"client1"}`,
+	)
+
+	// closing the day sends the last events and ends the stream
+	status, _ := s.do(http.MethodPost, "/close", "")
+	s.Require().Equal(http.StatusOK, status)
+
+	s.requireStreamEvents(text, "outgoing 19:00 11 client1")
+	_, _, err := s.readStreamEvent(text)
+	s.Require().ErrorIs(err, io.EOF)
+
+	status, _ = s.do(http.MethodGet, "/events/stream", "")
+	s.Require().Equal(http.StatusConflict, status)
+}
+
+func (s *serverSuite) TestStreamUnknownFormat() {
+	status, _ := s.do(http.MethodGet, "/events/stream?format=xml", "")
+	s.Require().Equal(http.StatusBadRequest, status)
+}
+
+func (s *serverSuite) TestStreamUnsubscribe() {
+	_, closeStream := s.openStream("")
+	closeStream()
+
+	// the handler notices the disconnected client asynchronously
+	s.Require().Eventually(func() bool {
+		return s.streamSubscribers() == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (s *serverSuite) TestBroadcastSlowSubscriber() {
+	b := newBroadcast(2)
+
+	slow, ok := b.subscribe()
+	s.Require().True(ok)
+
+	fast, ok := b.subscribe()
+	s.Require().True(ok)
+
+	at := time.Date(0, 1, 1, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		b.publish(model.NewIncomingEvent(at, model.Arrives, model.NewClientArrives("client1")))
+		<-fast
+	}
+
+	// buffered events are still delivered, then the channel is closed
+	s.Require().Len(slow, 2)
+	<-slow
+	<-slow
+	_, ok = <-slow
+	s.Require().False(ok)
+	s.Require().Equal(1, b.len())
+
+	b.close()
+	_, ok = <-fast
+	s.Require().False(ok)
+
+	_, ok = b.subscribe()
+	s.Require().False(ok)
+}
@@ -0,0 +1,117 @@
+package simulator
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"time"
+	"yadro-intern/internal/model"
+)
+
+// Options describe the generated working day of the club.
+type Options struct {
+	TablesCount  int
+	ClientsCount int
+	PricePerHour int
+	WorkingTime  *model.TimeInterval
+}
+
+// Generate writes the input file with a random working day of the club.
+//
+// Events are generated from the point of view of clients, so the file always has a valid format,
+// but some clients come before the opening, try to take busy tables or wait with free tables.
+func Generate(out io.Writer, opts *Options, rnd *rand.Rand, timeFormat string) error {
+	if _, err := fmt.Fprintf(
+		out, "%d\n%s %s\n%d\n",
+		opts.TablesCount,
+		opts.WorkingTime.Start.Format(timeFormat),
+		opts.WorkingTime.End.Format(timeFormat),
+		opts.PricePerHour,
+	); err != nil {
+		return err
+	}
+
+	events := make([]*model.IncomingEvent, 0, opts.ClientsCount*4)
+	for i := 1; i <= opts.ClientsCount; i++ {
+		events = append(events, clientEvents(fmt.Sprintf("client%d", i), opts, rnd)...)
+	}
+
+	// events of every client are in the chronological order,
+	// so the stable sort merges them keeping the order of the client's events
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].HappensAt.Before(events[j].HappensAt)
+	})
+
+	for _, event := range events {
+		if _, err := io.WriteString(out, event.String(timeFormat)+"\n"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// clientEvents generates the visit of a single client in the chronological order:
+// the client comes, sits at random tables or waits, and leaves, if the club is still opened.
+//
+// All events happen between an hour before the opening and the closing,
+// so their clock times are read back in the same order.
+func clientEvents(name string, opts *Options, rnd *rand.Rand) []*model.IncomingEvent {
+	var (
+		end      = opts.WorkingTime.End
+		earliest = earliestTime(opts.WorkingTime)
+	)
+
+	randomTime := func(from time.Time, maxDelay time.Duration) time.Time {
+		delay := time.Duration(rnd.Int63n(int64(maxDelay/time.Minute)+1)) * time.Minute
+		if at := from.Add(delay); at.Before(end) {
+			return at
+		}
+
+		return end
+	}
+
+	randomTable := func() int {
+		return rnd.Intn(opts.TablesCount) + 1
+	}
+
+	at := randomTime(earliest, end.Sub(earliest))
+	events := []*model.IncomingEvent{
+		model.NewIncomingEvent(at, model.Arrives, model.NewClientArrives(name)),
+	}
+
+	if rnd.Intn(5) == 0 {
+		at = randomTime(at, 15*time.Minute)
+		events = append(events, model.NewIncomingEvent(at, model.Waits, model.NewClientWaits(name)))
+	}
+
+	for sits := 1 + rnd.Intn(2); sits > 0; sits-- {
+		at = randomTime(at, 3*time.Hour)
+		events = append(events, model.NewIncomingEvent(at, model.Sits, model.NewClientSits(name, randomTable(), opts.TablesCount)))
+	}
+
+	at = randomTime(at, 3*time.Hour)
+	if at.Before(end) {
+		events = append(events, model.NewIncomingEvent(at, model.Leaves, model.NewClientLeaves(name)))
+	}
+
+	return events
+}
+
+// earliestTime returns an hour before the opening, but not earlier than the clock time,
+// which is still read as the same working day: the midnight or, if the club works over midnight, the closing.
+func earliestTime(workingTime *model.TimeInterval) time.Time {
+	start, end := workingTime.Start, workingTime.End
+
+	bound := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	if end.YearDay() != start.YearDay() {
+		bound = time.Date(start.Year(), start.Month(), start.Day(), end.Hour(), end.Minute()+1, 0, 0, start.Location())
+	}
+
+	if earliest := start.Add(-time.Hour); earliest.After(bound) {
+		return earliest
+	}
+
+	return bound
+}
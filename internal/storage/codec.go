@@ -0,0 +1,37 @@
+package storage
+
+import "encoding/json"
+
+// Codec converts values to bytes and back, so they can be persisted.
+type Codec[T any] interface {
+	Encode(value T) ([]byte, error)
+	Decode(data []byte) (T, error)
+}
+
+// JSONCodec persists values as JSON documents.
+type JSONCodec[T any] struct{}
+
+func (JSONCodec[T]) Encode(value T) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (JSONCodec[T]) Decode(data []byte) (T, error) {
+	var value T
+	err := json.Unmarshal(data, &value)
+	return value, err
+}
+
+// FuncCodec persists values by the functions,
+// for example, for interfaces, which can't be decoded as JSON.
+type FuncCodec[T any] struct {
+	EncodeFn func(value T) ([]byte, error)
+	DecodeFn func(data []byte) (T, error)
+}
+
+func (c FuncCodec[T]) Encode(value T) ([]byte, error) {
+	return c.EncodeFn(value)
+}
+
+func (c FuncCodec[T]) Decode(data []byte) (T, error) {
+	return c.DecodeFn(data)
+}
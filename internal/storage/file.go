@@ -0,0 +1,214 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// operations of the write-ahead log
+const (
+	opSet    = "set"
+	opDelete = "delete"
+	opPush   = "push"
+	opPop    = "pop"
+	opRemove = "remove"
+	opClear  = "clear"
+)
+
+type fileEntry struct {
+	Key   json.RawMessage `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+// FileStorage is the Storage persisted to the directory, it survives process restarts.
+//
+// Elements are kept in memory, every change is synced to the write-ahead log
+// before it's applied, the log is compacted to the snapshot, when it grows.
+//
+// Storage methods can't return errors, so the first persistence error is kept:
+// the storage stops changing and the error is returned by Err and Close.
+type FileStorage[K, V comparable] struct {
+	storage    map[K]V
+	wal        *wal
+	keyCodec   Codec[K]
+	valueCodec Codec[V]
+	err        error
+}
+
+// NewFileStorage opens the storage persisted to the directory, the missing directory is created.
+func NewFileStorage[K, V comparable](dir string, keyCodec Codec[K], valueCodec Codec[V]) (*FileStorage[K, V], error) {
+	s := &FileStorage[K, V]{
+		storage:    make(map[K]V),
+		keyCodec:   keyCodec,
+		valueCodec: valueCodec,
+	}
+
+	var entries []fileEntry
+	w, ops, err := openWAL(dir, &entries)
+	if err != nil {
+		return nil, err
+	}
+
+	s.wal = w
+	for _, entry := range entries {
+		if err = s.apply(&walOp{Op: opSet, Key: entry.Key, Value: entry.Value}); err != nil {
+			_ = w.close()
+			return nil, err
+		}
+	}
+
+	for _, op := range ops {
+		if err = s.apply(op); err != nil {
+			_ = w.close()
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+func (s *FileStorage[K, V]) Get(key K) (V, bool) {
+	value, ok := s.storage[key]
+	return value, ok
+}
+
+func (s *FileStorage[K, V]) Set(key K, value V) {
+	encodedKey, err := s.keyCodec.Encode(key)
+	if err != nil {
+		s.fail(fmt.Errorf("failed to encode key: %s", err))
+		return
+	}
+
+	encodedValue, err := s.valueCodec.Encode(value)
+	if err != nil {
+		s.fail(fmt.Errorf("failed to encode value: %s", err))
+		return
+	}
+
+	if s.commit(&walOp{Op: opSet, Key: encodedKey, Value: encodedValue}) {
+		s.storage[key] = value
+		s.compactIfNeeded()
+	}
+}
+
+func (s *FileStorage[K, V]) Delete(key K) {
+	if _, ok := s.storage[key]; !ok {
+		return
+	}
+
+	encodedKey, err := s.keyCodec.Encode(key)
+	if err != nil {
+		s.fail(fmt.Errorf("failed to encode key: %s", err))
+		return
+	}
+
+	if s.commit(&walOp{Op: opDelete, Key: encodedKey}) {
+		delete(s.storage, key)
+		s.compactIfNeeded()
+	}
+}
+
+func (s *FileStorage[K, V]) Len() int {
+	return len(s.storage)
+}
+
+func (s *FileStorage[K, V]) GetAll() []Pair[K, V] {
+	pairs := make([]Pair[K, V], 0, len(s.storage))
+	for key, value := range s.storage {
+		pairs = append(pairs, Pair[K, V]{Key: key, Value: value})
+	}
+
+	return pairs
+}
+
+// Compact replaces the snapshot with the current elements and clears the write-ahead log.
+func (s *FileStorage[K, V]) Compact() error {
+	if s.err != nil {
+		return s.err
+	}
+
+	entries := make([]fileEntry, 0, len(s.storage))
+	for key, value := range s.storage {
+		encodedKey, err := s.keyCodec.Encode(key)
+		if err != nil {
+			return fmt.Errorf("failed to encode key: %s", err)
+		}
+
+		encodedValue, err := s.valueCodec.Encode(value)
+		if err != nil {
+			return fmt.Errorf("failed to encode value: %s", err)
+		}
+
+		entries = append(entries, fileEntry{Key: encodedKey, Value: encodedValue})
+	}
+
+	if err := s.wal.compact(entries); err != nil {
+		s.fail(err)
+	}
+
+	return s.err
+}
+
+// Err returns the first persistence error, after it the storage isn't changed anymore.
+func (s *FileStorage[K, V]) Err() error {
+	return s.err
+}
+
+// Close closes the write-ahead log and returns the first persistence error.
+func (s *FileStorage[K, V]) Close() error {
+	if err := s.wal.close(); err != nil && s.err == nil {
+		s.err = err
+	}
+
+	return s.err
+}
+
+// apply applies the logged operation to the elements in memory.
+func (s *FileStorage[K, V]) apply(op *walOp) error {
+	key, err := s.keyCodec.Decode(op.Key)
+	if err != nil {
+		return fmt.Errorf("failed to decode key: %s", err)
+	}
+
+	switch op.Op {
+	case opSet:
+		value, err := s.valueCodec.Decode(op.Value)
+		if err != nil {
+			return fmt.Errorf("failed to decode value: %s", err)
+		}
+
+		s.storage[key] = value
+	case opDelete:
+		delete(s.storage, key)
+	default:
+		return fmt.Errorf("unknown storage operation: %s", op.Op)
+	}
+
+	return nil
+}
+
+// commit logs the operation, false means, that the operation can't be applied.
+func (s *FileStorage[K, V]) commit(op *walOp) bool {
+	if s.err != nil {
+		return false
+	}
+
+	if err := s.wal.append(op); err != nil {
+		s.fail(err)
+		return false
+	}
+
+	return true
+}
+
+func (s *FileStorage[K, V]) compactIfNeeded() {
+	if s.wal.shouldCompact(len(s.storage)) {
+		_ = s.Compact()
+	}
+}
+
+func (s *FileStorage[K, V]) fail(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+}
@@ -0,0 +1,231 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// FileQueue is the Queue persisted to the directory, it survives process restarts.
+//
+// It's persisted in the same way as FileStorage, the first persistence error
+// is returned by Err and Close, the queue isn't changed after it.
+type FileQueue[T any] struct {
+	queue    []T
+	nilValue T
+	wal      *wal
+	codec    Codec[T]
+	err      error
+}
+
+// NewFileQueue opens the queue persisted to the directory, the missing directory is created.
+func NewFileQueue[T any](dir string, codec Codec[T], nilValue T) (*FileQueue[T], error) {
+	q := &FileQueue[T]{
+		queue:    make([]T, 0),
+		nilValue: nilValue,
+		codec:    codec,
+	}
+
+	var values []json.RawMessage
+	w, ops, err := openWAL(dir, &values)
+	if err != nil {
+		return nil, err
+	}
+
+	q.wal = w
+	for _, value := range values {
+		if err = q.apply(&walOp{Op: opPush, Value: value}); err != nil {
+			_ = w.close()
+			return nil, err
+		}
+	}
+
+	for _, op := range ops {
+		if err = q.apply(op); err != nil {
+			_ = w.close()
+			return nil, err
+		}
+	}
+
+	return q, nil
+}
+
+func (q *FileQueue[T]) Push(value T) {
+	encoded, err := q.codec.Encode(value)
+	if err != nil {
+		q.fail(fmt.Errorf("failed to encode value: %s", err))
+		return
+	}
+
+	if q.commit(&walOp{Op: opPush, Value: encoded}) {
+		q.queue = append(q.queue, value)
+		q.compactIfNeeded()
+	}
+}
+
+func (q *FileQueue[T]) Pop() (T, error) {
+	top, err := q.Peek()
+	if err != nil {
+		return q.nilValue, err
+	}
+
+	if !q.commit(&walOp{Op: opPop}) {
+		return q.nilValue, q.err
+	}
+
+	q.queue = q.queue[1:]
+	q.compactIfNeeded()
+	return top, nil
+}
+
+func (q *FileQueue[T]) Len() int {
+	return len(q.queue)
+}
+
+func (q *FileQueue[T]) Peek() (T, error) {
+	if len(q.queue) == 0 {
+		return q.nilValue, errors.New("queue is empty")
+	}
+
+	return q.queue[0], nil
+}
+
+func (q *FileQueue[T]) Clear() {
+	if len(q.queue) == 0 {
+		return
+	}
+
+	if q.commit(&walOp{Op: opClear}) {
+		q.queue = q.queue[:0]
+		q.compactIfNeeded()
+	}
+}
+
+func (q *FileQueue[T]) Contains(match func(T) bool) bool {
+	return q.index(match) != -1
+}
+
+func (q *FileQueue[T]) Remove(match func(T) bool) bool {
+	idx := q.index(match)
+	if idx == -1 {
+		return false
+	}
+
+	if !q.commit(&walOp{Op: opRemove, Index: idx}) {
+		return false
+	}
+
+	q.queue = append(q.queue[:idx], q.queue[idx+1:]...)
+	q.compactIfNeeded()
+	return true
+}
+
+func (q *FileQueue[T]) GetAll() []T {
+	values := make([]T, len(q.queue))
+	copy(values, q.queue)
+	return values
+}
+
+// Compact replaces the snapshot with the current elements and clears the write-ahead log.
+func (q *FileQueue[T]) Compact() error {
+	if q.err != nil {
+		return q.err
+	}
+
+	values := make([]json.RawMessage, 0, len(q.queue))
+	for _, value := range q.queue {
+		encoded, err := q.codec.Encode(value)
+		if err != nil {
+			return fmt.Errorf("failed to encode value: %s", err)
+		}
+
+		values = append(values, encoded)
+	}
+
+	if err := q.wal.compact(values); err != nil {
+		q.fail(err)
+	}
+
+	return q.err
+}
+
+// Err returns the first persistence error, after it the queue isn't changed anymore.
+func (q *FileQueue[T]) Err() error {
+	return q.err
+}
+
+// Close closes the write-ahead log and returns the first persistence error.
+func (q *FileQueue[T]) Close() error {
+	if err := q.wal.close(); err != nil && q.err == nil {
+		q.err = err
+	}
+
+	return q.err
+}
+
+func (q *FileQueue[T]) index(match func(T) bool) int {
+	for idx, value := range q.queue {
+		if match(value) {
+			return idx
+		}
+	}
+
+	return -1
+}
+
+// apply applies the logged operation to the elements in memory.
+func (q *FileQueue[T]) apply(op *walOp) error {
+	switch op.Op {
+	case opPush:
+		value, err := q.codec.Decode(op.Value)
+		if err != nil {
+			return fmt.Errorf("failed to decode value: %s", err)
+		}
+
+		q.queue = append(q.queue, value)
+	case opPop:
+		if len(q.queue) == 0 {
+			return errors.New("write-ahead log pops the empty queue")
+		}
+
+		q.queue = q.queue[1:]
+	case opRemove:
+		if op.Index < 0 || op.Index >= len(q.queue) {
+			return fmt.Errorf("write-ahead log removes the missing element %d", op.Index)
+		}
+
+		q.queue = append(q.queue[:op.Index], q.queue[op.Index+1:]...)
+	case opClear:
+		q.queue = q.queue[:0]
+	default:
+		return fmt.Errorf("unknown queue operation: %s", op.Op)
+	}
+
+	return nil
+}
+
+// commit logs the operation, false means, that the operation can't be applied.
+func (q *FileQueue[T]) commit(op *walOp) bool {
+	if q.err != nil {
+		return false
+	}
+
+	if err := q.wal.append(op); err != nil {
+		q.fail(err)
+		return false
+	}
+
+	return true
+}
+
+func (q *FileQueue[T]) compactIfNeeded() {
+	if q.wal.shouldCompact(len(q.queue)) {
+		_ = q.Compact()
+	}
+}
+
+func (q *FileQueue[T]) fail(err error) {
+	if q.err == nil {
+		q.err = err
+	}
+}
@@ -0,0 +1,224 @@
+package storage
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/suite"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"yadro-intern/internal/model"
+)
+
+type fileSuite struct {
+	suite.Suite
+
+	dir string
+}
+
+func TestFile(t *testing.T) {
+	suite.Run(t, new(fileSuite))
+}
+
+func (s *fileSuite) SetupTest() {
+	s.dir = s.T().TempDir()
+}
+
+func (s *fileSuite) openStorage() *FileStorage[string, int] {
+	storage, err := NewFileStorage[string, int](s.dir, JSONCodec[string]{}, JSONCodec[int]{})
+	s.Require().NoError(err)
+	return storage
+}
+
+func (s *fileSuite) openQueue() *FileQueue[string] {
+	queue, err := NewFileQueue[string](s.dir, JSONCodec[string]{}, "")
+	s.Require().NoError(err)
+	return queue
+}
+
+func (s *fileSuite) TestStorageReopen() {
+	storage := s.openStorage()
+	storage.Set("client1", 1)
+	storage.Set("client2", 2)
+	storage.Set("client1", 3)
+	storage.Delete("client2")
+	s.Require().NoError(storage.Close())
+
+	storage = s.openStorage()
+	defer storage.Close()
+
+	s.Require().Equal([]Pair[string, int]{{Key: "client1", Value: 3}}, storage.GetAll())
+}
+
+func (s *fileSuite) TestQueueReopen() {
+	queue := s.openQueue()
+	for _, value := range []string{"client1", "client2", "client3", "client4"} {
+		queue.Push(value)
+	}
+
+	_, err := queue.Pop()
+	s.Require().NoError(err)
+	s.Require().True(queue.Remove(func(value string) bool { return value == "client3" }))
+	s.Require().NoError(queue.Close())
+
+	queue = s.openQueue()
+	s.Require().Equal([]string{"client2", "client4"}, queue.GetAll())
+
+	queue.Clear()
+	queue.Push("client5")
+	s.Require().NoError(queue.Close())
+
+	queue = s.openQueue()
+	defer queue.Close()
+
+	s.Require().Equal([]string{"client5"}, queue.GetAll())
+}
+
+func (s *fileSuite) TestTornTail() {
+	storage := s.openStorage()
+	storage.Set("client1", 1)
+	s.Require().NoError(storage.Close())
+
+	// the crash happened while writing the operation
+	f, err := os.OpenFile(filepath.Join(s.dir, walFile), os.O_APPEND|os.O_WRONLY, 0o600)
+	s.Require().NoError(err)
+	_, err = f.WriteString(`{"seq":2,"op":"set","key":"clie`)
+	s.Require().NoError(err)
+	s.Require().NoError(f.Close())
+
+	storage = s.openStorage()
+	storage.Set("client2", 2)
+	s.Require().NoError(storage.Close())
+
+	storage = s.openStorage()
+	defer storage.Close()
+
+	s.Require().Equal(2, storage.Len())
+	value, ok := storage.Get("client2")
+	s.Require().True(ok)
+	s.Require().Equal(2, value)
+}
+
+func (s *fileSuite) TestInvalidLog() {
+	s.Require().NoError(os.WriteFile(filepath.Join(s.dir, walFile), []byte("not an operation\n"), 0o600))
+
+	_, err := NewFileStorage[string, int](s.dir, JSONCodec[string]{}, JSONCodec[int]{})
+	s.Require().Error(err)
+}
+
+func (s *fileSuite) TestCompaction() {
+	storage := s.openStorage()
+	for i := 0; i < 2*minCompactOps; i++ {
+		storage.Set("client1", i)
+	}
+
+	s.Require().NoError(storage.Err())
+	s.Require().Less(s.logLines(), minCompactOps)
+	s.Require().NoError(storage.Close())
+
+	storage = s.openStorage()
+	defer storage.Close()
+
+	value, ok := storage.Get("client1")
+	s.Require().True(ok)
+	s.Require().Equal(2*minCompactOps-1, value)
+}
+
+func (s *fileSuite) TestCrashAfterSnapshot() {
+	queue := s.openQueue()
+	queue.Push("client1")
+	queue.Push("client2")
+
+	log, err := os.ReadFile(filepath.Join(s.dir, walFile))
+	s.Require().NoError(err)
+
+	s.Require().NoError(queue.Compact())
+	queue.Push("client3")
+	s.Require().NoError(queue.Close())
+
+	// the crash happened after the snapshot is written, but before the log is cleared
+	s.Require().NoError(os.WriteFile(filepath.Join(s.dir, walFile), log, 0o600))
+
+	queue = s.openQueue()
+	s.Require().Equal([]string{"client1", "client2"}, queue.GetAll())
+
+	queue.Push("client4")
+	s.Require().NoError(queue.Close())
+
+	queue = s.openQueue()
+	defer queue.Close()
+
+	s.Require().Equal([]string{"client1", "client2", "client4"}, queue.GetAll())
+}
+
+func (s *fileSuite) TestStickyError() {
+	storage := s.openStorage()
+	storage.Set("client1", 1)
+	s.Require().NoError(storage.wal.close())
+
+	storage.Set("client2", 2)
+	s.Require().Error(storage.Err())
+
+	storage.Delete("client1")
+	s.Require().Equal(1, storage.Len())
+	s.Require().Error(storage.Compact())
+	s.Require().Error(storage.Close())
+}
+
+func (s *fileSuite) TestModelCodecs() {
+	clients, err := NewFileStorage[string, model.ClientData](
+		filepath.Join(s.dir, "clients"),
+		JSONCodec[string]{},
+		FuncCodec[model.ClientData]{EncodeFn: model.MarshalClientData, DecodeFn: model.UnmarshalClientData},
+	)
+	s.Require().NoError(err)
+
+	sits, ok := model.NewClientData(model.Sits, "client1", 2, 3, nil)
+	s.Require().True(ok)
+	clients.Set("client1", sits)
+	s.Require().NoError(clients.Close())
+
+	events, err := NewFileQueue[*model.IncomingEvent](
+		filepath.Join(s.dir, "events"), JSONCodec[*model.IncomingEvent]{}, nil,
+	)
+	s.Require().NoError(err)
+
+	waits, ok := model.NewClientData(model.Waits, "client2", 0, 3, nil)
+	s.Require().True(ok)
+	event := &model.IncomingEvent{HappensAt: time.Date(0, 1, 1, 10, 15, 0, 0, time.UTC), Type: model.Waits, Client: waits}
+	events.Push(event)
+
+	reserves := model.NewClientReserves("client3", 1, 3, model.NewTimeInterval(
+		time.Date(0, 1, 1, 12, 0, 0, 0, time.UTC),
+		time.Date(0, 1, 1, 13, 0, 0, 0, time.UTC),
+	))
+	reservation := &model.IncomingEvent{HappensAt: time.Date(0, 1, 1, 10, 20, 0, 0, time.UTC), Type: model.Reserves, Client: reserves}
+	events.Push(reservation)
+	s.Require().NoError(events.Close())
+
+	clients, err = NewFileStorage[string, model.ClientData](
+		filepath.Join(s.dir, "clients"),
+		JSONCodec[string]{},
+		FuncCodec[model.ClientData]{EncodeFn: model.MarshalClientData, DecodeFn: model.UnmarshalClientData},
+	)
+	s.Require().NoError(err)
+	defer clients.Close()
+
+	client, ok := clients.Get("client1")
+	s.Require().True(ok)
+	s.Require().Equal(sits, client)
+
+	events, err = NewFileQueue[*model.IncomingEvent](
+		filepath.Join(s.dir, "events"), JSONCodec[*model.IncomingEvent]{}, nil,
+	)
+	s.Require().NoError(err)
+	defer events.Close()
+
+	s.Require().Equal([]*model.IncomingEvent{event, reservation}, events.GetAll())
+}
+
+func (s *fileSuite) logLines() int {
+	content, err := os.ReadFile(filepath.Join(s.dir, walFile))
+	s.Require().NoError(err)
+	return bytes.Count(content, []byte("\n"))
+}
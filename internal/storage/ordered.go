@@ -0,0 +1,232 @@
+package storage
+
+import "math/rand"
+
+// Ordered is a constraint for the keys, which can be compared with the < operator.
+type Ordered interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64 | ~string
+}
+
+// Less is the natural order of the keys for OrderedStorage.
+func Less[K Ordered](a, b K) bool {
+	return a < b
+}
+
+// skip list parameters: every level has a quarter of the nodes of the previous one,
+// 16 levels are enough for billions of elements.
+const (
+	maxLevel    = 16
+	levelFactor = 4
+)
+
+// OrderedStorage is the Storage keeping the elements sorted by key in the skip list,
+// so GetAll and the iteration return them in the key order.
+//
+// Get, Set and Delete take O(log n) on average.
+type OrderedStorage[K, V comparable] struct {
+	head  *skipNode[K, V]
+	tail  *skipNode[K, V]
+	level int
+	len   int
+	less  func(a, b K) bool
+	rnd   *rand.Rand
+}
+
+type skipNode[K, V comparable] struct {
+	key   K
+	value V
+	next  []*skipNode[K, V]
+
+	// prev is the previous node of the lowest level, it's nil for the first one.
+	prev *skipNode[K, V]
+}
+
+// NewOrderedStorage creates the storage with the order of the keys,
+// for example, storage.Less[int].
+func NewOrderedStorage[K, V comparable](less func(a, b K) bool) *OrderedStorage[K, V] {
+	return &OrderedStorage[K, V]{
+		head:  &skipNode[K, V]{next: make([]*skipNode[K, V], maxLevel)},
+		level: 1,
+		less:  less,
+
+		// levels don't have to be unpredictable, the fixed seed makes the structure reproducible
+		rnd: rand.New(rand.NewSource(1)), // #nosec G404
+	}
+}
+
+func (o *OrderedStorage[K, V]) Get(key K) (V, bool) {
+	if node := o.find(key); node != nil {
+		return node.value, true
+	}
+
+	var zero V
+	return zero, false
+}
+
+func (o *OrderedStorage[K, V]) Set(key K, value V) {
+	var update [maxLevel]*skipNode[K, V]
+	node := o.head
+	for lvl := o.level - 1; lvl >= 0; lvl-- {
+		for node.next[lvl] != nil && o.less(node.next[lvl].key, key) {
+			node = node.next[lvl]
+		}
+
+		update[lvl] = node
+	}
+
+	if next := node.next[0]; next != nil && !o.less(key, next.key) {
+		next.value = value
+		return
+	}
+
+	level := o.randomLevel()
+	for ; o.level < level; o.level++ {
+		update[o.level] = o.head
+	}
+
+	inserted := &skipNode[K, V]{key: key, value: value, next: make([]*skipNode[K, V], level)}
+	for lvl := 0; lvl < level; lvl++ {
+		inserted.next[lvl] = update[lvl].next[lvl]
+		update[lvl].next[lvl] = inserted
+	}
+
+	if update[0] != o.head {
+		inserted.prev = update[0]
+	}
+
+	if inserted.next[0] != nil {
+		inserted.next[0].prev = inserted
+	} else {
+		o.tail = inserted
+	}
+
+	o.len++
+}
+
+func (o *OrderedStorage[K, V]) Delete(key K) {
+	var update [maxLevel]*skipNode[K, V]
+	node := o.head
+	for lvl := o.level - 1; lvl >= 0; lvl-- {
+		for node.next[lvl] != nil && o.less(node.next[lvl].key, key) {
+			node = node.next[lvl]
+		}
+
+		update[lvl] = node
+	}
+
+	deleted := node.next[0]
+	if deleted == nil || o.less(key, deleted.key) {
+		return
+	}
+
+	for lvl := 0; lvl < len(deleted.next); lvl++ {
+		update[lvl].next[lvl] = deleted.next[lvl]
+	}
+
+	if deleted.next[0] != nil {
+		deleted.next[0].prev = deleted.prev
+	} else {
+		o.tail = deleted.prev
+	}
+
+	for o.level > 1 && o.head.next[o.level-1] == nil {
+		o.level--
+	}
+
+	o.len--
+}
+
+func (o *OrderedStorage[K, V]) Len() int {
+	return o.len
+}
+
+// GetAll returns all elements sorted by key.
+func (o *OrderedStorage[K, V]) GetAll() []Pair[K, V] {
+	pairs := make([]Pair[K, V], 0, o.len)
+	o.Ascend(func(key K, value V) bool {
+		pairs = append(pairs, Pair[K, V]{Key: key, Value: value})
+		return true
+	})
+
+	return pairs
+}
+
+// Range returns the elements with the keys from the from key inclusive
+// to the to key exclusive, sorted by key.
+func (o *OrderedStorage[K, V]) Range(from, to K) []Pair[K, V] {
+	var pairs []Pair[K, V]
+	for node := o.seek(from); node != nil && o.less(node.key, to); node = node.next[0] {
+		pairs = append(pairs, Pair[K, V]{Key: node.key, Value: node.value})
+	}
+
+	return pairs
+}
+
+// Ascend calls fn for the elements in the ascending order of the keys,
+// the iteration stops, when fn returns false.
+func (o *OrderedStorage[K, V]) Ascend(fn func(key K, value V) bool) {
+	for node := o.head.next[0]; node != nil; node = node.next[0] {
+		if !fn(node.key, node.value) {
+			return
+		}
+	}
+}
+
+// Descend calls fn for the elements in the descending order of the keys,
+// the iteration stops, when fn returns false.
+func (o *OrderedStorage[K, V]) Descend(fn func(key K, value V) bool) {
+	for node := o.tail; node != nil; node = node.prev {
+		if !fn(node.key, node.value) {
+			return
+		}
+	}
+}
+
+// Min returns the element with the least key, ok is false for the empty storage.
+func (o *OrderedStorage[K, V]) Min() (pair Pair[K, V], ok bool) {
+	if node := o.head.next[0]; node != nil {
+		return Pair[K, V]{Key: node.key, Value: node.value}, true
+	}
+
+	return pair, false
+}
+
+// Max returns the element with the greatest key, ok is false for the empty storage.
+func (o *OrderedStorage[K, V]) Max() (pair Pair[K, V], ok bool) {
+	if o.tail != nil {
+		return Pair[K, V]{Key: o.tail.key, Value: o.tail.value}, true
+	}
+
+	return pair, false
+}
+
+// seek returns the first node with the key not less than the key.
+func (o *OrderedStorage[K, V]) seek(key K) *skipNode[K, V] {
+	node := o.head
+	for lvl := o.level - 1; lvl >= 0; lvl-- {
+		for node.next[lvl] != nil && o.less(node.next[lvl].key, key) {
+			node = node.next[lvl]
+		}
+	}
+
+	return node.next[0]
+}
+
+func (o *OrderedStorage[K, V]) find(key K) *skipNode[K, V] {
+	if node := o.seek(key); node != nil && !o.less(key, node.key) {
+		return node
+	}
+
+	return nil
+}
+
+func (o *OrderedStorage[K, V]) randomLevel() int {
+	level := 1
+	for level < maxLevel && o.rnd.Intn(levelFactor) == 0 {
+		level++
+	}
+
+	return level
+}
@@ -0,0 +1,152 @@
+package storage_test
+
+import (
+	"github.com/stretchr/testify/suite"
+	"math/rand"
+	"sort"
+	"testing"
+	"yadro-intern/internal/storage"
+)
+
+type orderedSuite struct {
+	suite.Suite
+
+	storage *storage.OrderedStorage[int, string]
+}
+
+func TestOrdered(t *testing.T) {
+	suite.Run(t, new(orderedSuite))
+}
+
+func (s *orderedSuite) SetupTest() {
+	s.storage = storage.NewOrderedStorage[int, string](storage.Less[int])
+	for _, key := range []int{5, 1, 9, 3, 7} {
+		s.storage.Set(key, "table")
+	}
+}
+
+func (s *orderedSuite) keys(pairs []storage.Pair[int, string]) []int {
+	keys := make([]int, 0, len(pairs))
+	for _, pair := range pairs {
+		keys = append(keys, pair.Key)
+	}
+
+	return keys
+}
+
+func (s *orderedSuite) TestGetAll() {
+	s.Require().Equal([]int{1, 3, 5, 7, 9}, s.keys(s.storage.GetAll()))
+
+	s.storage.Delete(1)
+	s.storage.Delete(9)
+	s.storage.Set(4, "table")
+	s.Require().Equal([]int{3, 4, 5, 7}, s.keys(s.storage.GetAll()))
+}
+
+func (s *orderedSuite) TestRange() {
+	testcases := []struct {
+		name     string
+		from, to int
+		expected []int
+	}{
+		{name: "inner", from: 3, to: 7, expected: []int{3, 5}},
+		{name: "between keys", from: 2, to: 8, expected: []int{3, 5, 7}},
+		{name: "all", from: 0, to: 10, expected: []int{1, 3, 5, 7, 9}},
+		{name: "empty", from: 5, to: 5, expected: []int{}},
+		{name: "reversed", from: 7, to: 3, expected: []int{}},
+		{name: "after all", from: 10, to: 20, expected: []int{}},
+	}
+
+	for _, tc := range testcases {
+		s.Run(tc.name, func() {
+			s.Require().Equal(tc.expected, s.keys(s.storage.Range(tc.from, tc.to)))
+		})
+	}
+}
+
+func (s *orderedSuite) TestAscendDescend() {
+	var keys []int
+	s.storage.Ascend(func(key int, _ string) bool {
+		keys = append(keys, key)
+		return key < 5
+	})
+	s.Require().Equal([]int{1, 3, 5}, keys)
+
+	keys = nil
+	s.storage.Descend(func(key int, _ string) bool {
+		keys = append(keys, key)
+		return true
+	})
+	s.Require().Equal([]int{9, 7, 5, 3, 1}, keys)
+}
+
+func (s *orderedSuite) TestMinMax() {
+	pair, ok := s.storage.Min()
+	s.Require().True(ok)
+	s.Require().Equal(1, pair.Key)
+
+	pair, ok = s.storage.Max()
+	s.Require().True(ok)
+	s.Require().Equal(9, pair.Key)
+
+	s.storage.Delete(9)
+	pair, ok = s.storage.Max()
+	s.Require().True(ok)
+	s.Require().Equal(7, pair.Key)
+
+	empty := storage.NewOrderedStorage[int, string](storage.Less[int])
+	_, ok = empty.Min()
+	s.Require().False(ok)
+	_, ok = empty.Max()
+	s.Require().False(ok)
+}
+
+// TestRandomizedOrder compares the order with the sorted keys of the map after random changes.
+func (s *orderedSuite) TestRandomizedOrder() {
+	var (
+		ordered   = storage.NewOrderedStorage[int, string](storage.Less[int])
+		reference = make(map[int]bool)
+		rnd       = rand.New(rand.NewSource(1)) // #nosec G404
+	)
+
+	for op := 0; op < 5000; op++ {
+		key := rnd.Intn(500)
+		if rnd.Intn(3) == 0 {
+			ordered.Delete(key)
+			delete(reference, key)
+			continue
+		}
+
+		ordered.Set(key, "table")
+		reference[key] = true
+	}
+
+	expected := make([]int, 0, len(reference))
+	for key := range reference {
+		expected = append(expected, key)
+	}
+	sort.Ints(expected)
+
+	s.Require().Equal(expected, s.keys(ordered.GetAll()))
+
+	var descending []int
+	ordered.Descend(func(key int, _ string) bool {
+		descending = append(descending, key)
+		return true
+	})
+
+	for i, key := range descending {
+		s.Require().Equal(expected[len(expected)-1-i], key)
+	}
+}
+
+func BenchmarkOrderedStorage(b *testing.B) {
+	s := storage.NewOrderedStorage[int, int](storage.Less[int])
+	for i := 0; i < b.N; i++ {
+		s.Set(i*7919%b.N, i)
+	}
+
+	for i := 0; i < b.N; i++ {
+		s.Get(i)
+	}
+}
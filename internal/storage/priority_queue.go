@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"errors"
+	"sort"
+)
+
+// PriorityQueue is the Queue with the priority lanes: elements with the less priority
+// are popped first, elements with the same priority are popped in FIFO order.
+type PriorityQueue[T any] struct {
+	// lanes are sorted by priority, empty lanes are removed.
+	lanes    []*lane[T]
+	priority func(T) int
+	nilValue T
+	len      int
+}
+
+type lane[T any] struct {
+	priority int
+	values   []T
+}
+
+// NewPriorityQueue creates the queue, the priority of the element mustn't change while it's queued.
+func NewPriorityQueue[T any](nilValue T, priority func(T) int) Queue[T] {
+	return &PriorityQueue[T]{
+		priority: priority,
+		nilValue: nilValue,
+	}
+}
+
+func (q *PriorityQueue[T]) Push(value T) {
+	priority := q.priority(value)
+	idx := sort.Search(len(q.lanes), func(i int) bool {
+		return q.lanes[i].priority >= priority
+	})
+
+	if idx == len(q.lanes) || q.lanes[idx].priority != priority {
+		q.lanes = append(q.lanes, nil)
+		copy(q.lanes[idx+1:], q.lanes[idx:])
+		q.lanes[idx] = &lane[T]{priority: priority}
+	}
+
+	q.lanes[idx].values = append(q.lanes[idx].values, value)
+	q.len++
+}
+
+func (q *PriorityQueue[T]) Pop() (T, error) {
+	top, err := q.Peek()
+	if err != nil {
+		return q.nilValue, err
+	}
+
+	q.removeAt(0, 0)
+	return top, nil
+}
+
+func (q *PriorityQueue[T]) Len() int {
+	return q.len
+}
+
+func (q *PriorityQueue[T]) Peek() (T, error) {
+	if q.len == 0 {
+		return q.nilValue, errors.New("queue is empty")
+	}
+
+	return q.lanes[0].values[0], nil
+}
+
+func (q *PriorityQueue[T]) Clear() {
+	q.lanes = nil
+	q.len = 0
+}
+
+func (q *PriorityQueue[T]) Contains(match func(T) bool) bool {
+	laneIdx, _ := q.index(match)
+	return laneIdx != -1
+}
+
+func (q *PriorityQueue[T]) Remove(match func(T) bool) bool {
+	laneIdx, idx := q.index(match)
+	if laneIdx == -1 {
+		return false
+	}
+
+	q.removeAt(laneIdx, idx)
+	return true
+}
+
+func (q *PriorityQueue[T]) GetAll() []T {
+	values := make([]T, 0, q.len)
+	for _, l := range q.lanes {
+		values = append(values, l.values...)
+	}
+
+	return values
+}
+
+func (q *PriorityQueue[T]) index(match func(T) bool) (laneIdx, idx int) {
+	for laneIdx, l := range q.lanes {
+		for idx, value := range l.values {
+			if match(value) {
+				return laneIdx, idx
+			}
+		}
+	}
+
+	return -1, -1
+}
+
+func (q *PriorityQueue[T]) removeAt(laneIdx, idx int) {
+	l := q.lanes[laneIdx]
+	l.values = append(l.values[:idx], l.values[idx+1:]...)
+	if len(l.values) == 0 {
+		q.lanes = append(q.lanes[:laneIdx], q.lanes[laneIdx+1:]...)
+	}
+
+	q.len--
+}
@@ -0,0 +1,70 @@
+package storage_test
+
+import (
+	"github.com/stretchr/testify/suite"
+	"strings"
+	"testing"
+	"yadro-intern/internal/storage"
+)
+
+type priorityQueueSuite struct {
+	suite.Suite
+
+	queue storage.Queue[string]
+}
+
+func TestPriorityQueueLanes(t *testing.T) {
+	suite.Run(t, new(priorityQueueSuite))
+}
+
+// priority is the digit before the name, "0vip" goes before "1client".
+func priority(value string) int {
+	return int(value[0] - '0')
+}
+
+func (s *priorityQueueSuite) SetupTest() {
+	s.queue = storage.NewPriorityQueue[string]("", priority)
+	for _, value := range []string{"2walkin1", "1member1", "2walkin2", "0vip", "1member2"} {
+		s.queue.Push(value)
+	}
+}
+
+func (s *priorityQueueSuite) TestOrder() {
+	s.Require().Equal([]string{"0vip", "1member1", "1member2", "2walkin1", "2walkin2"}, s.queue.GetAll())
+
+	var popped []string
+	for s.queue.Len() > 0 {
+		value, err := s.queue.Pop()
+		s.Require().NoError(err)
+		popped = append(popped, value)
+	}
+
+	s.Require().Equal([]string{"0vip", "1member1", "1member2", "2walkin1", "2walkin2"}, popped)
+}
+
+func (s *priorityQueueSuite) TestPushAfterPop() {
+	value, err := s.queue.Pop()
+	s.Require().NoError(err)
+	s.Require().Equal("0vip", value)
+
+	// the lane is created again for the new element
+	s.queue.Push("0vip2")
+	s.queue.Push("1member3")
+
+	value, err = s.queue.Peek()
+	s.Require().NoError(err)
+	s.Require().Equal("0vip2", value)
+	s.Require().Equal([]string{"0vip2", "1member1", "1member2", "1member3", "2walkin1", "2walkin2"}, s.queue.GetAll())
+}
+
+func (s *priorityQueueSuite) TestRemove() {
+	isMember := func(value string) bool {
+		return strings.Contains(value, "member")
+	}
+
+	s.Require().True(s.queue.Remove(isMember))
+	s.Require().True(s.queue.Remove(isMember))
+	s.Require().False(s.queue.Contains(isMember))
+	s.Require().Equal(3, s.queue.Len())
+	s.Require().Equal([]string{"0vip", "2walkin1", "2walkin2"}, s.queue.GetAll())
+}
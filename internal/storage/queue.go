@@ -19,6 +19,17 @@ type Queue[T any] interface {
 
 	// Clear removes all elements from the queue.
 	Clear()
+
+	// Contains reports whether the queue has an element matching the predicate.
+	Contains(match func(T) bool) bool
+
+	// Remove removes the first element matching the predicate,
+	// keeping the order of the others.
+	// It returns false if there is no such element.
+	Remove(match func(T) bool) bool
+
+	// GetAll returns all elements of the queue in the order they will be popped.
+	GetAll() []T
 }
 
 type InMemoryQueue[T any] struct {
@@ -63,3 +74,33 @@ func (i *InMemoryQueue[T]) Peek() (T, error) {
 func (i *InMemoryQueue[T]) Clear() {
 	i.queue = i.queue[:0]
 }
+
+func (i *InMemoryQueue[T]) Contains(match func(T) bool) bool {
+	return i.index(match) != -1
+}
+
+func (i *InMemoryQueue[T]) Remove(match func(T) bool) bool {
+	idx := i.index(match)
+	if idx == -1 {
+		return false
+	}
+
+	i.queue = append(i.queue[:idx], i.queue[idx+1:]...)
+	return true
+}
+
+func (i *InMemoryQueue[T]) GetAll() []T {
+	values := make([]T, len(i.queue))
+	copy(values, i.queue)
+	return values
+}
+
+func (i *InMemoryQueue[T]) index(match func(T) bool) int {
+	for idx, value := range i.queue {
+		if match(value) {
+			return idx
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"hash/fnv"
+	"sync"
+)
+
+// ShardedStorage is the Storage safe for concurrent use under high contention:
+// keys are spread by the hash over the shards with their own locks,
+// so writers of different shards don't block each other.
+//
+// Len and GetAll lock the shards one by one, so they aren't atomic
+// comparing to the concurrent changes.
+type ShardedStorage[K, V comparable] struct {
+	shards []*shard[K, V]
+	hash   func(K) uint64
+}
+
+type shard[K, V comparable] struct {
+	mu      sync.RWMutex
+	storage map[K]V
+}
+
+// NewShardedStorage creates the storage with the count of shards, at least one,
+// the hash function must return the same value for the equal keys.
+func NewShardedStorage[K, V comparable](shards int, hash func(K) uint64) Storage[K, V] {
+	if shards < 1 {
+		shards = 1
+	}
+
+	s := &ShardedStorage[K, V]{shards: make([]*shard[K, V], shards), hash: hash}
+	for i := range s.shards {
+		s.shards[i] = &shard[K, V]{storage: make(map[K]V)}
+	}
+
+	return s
+}
+
+// HashString is the hash function for the string keys of ShardedStorage.
+func HashString(key string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return h.Sum64()
+}
+
+// HashInt is the hash function for the int keys of ShardedStorage.
+func HashInt(key int) uint64 {
+	// mixes the bits, so the sequential keys are spread over the shards
+	x := uint64(key)
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	return x
+}
+
+func (s *ShardedStorage[K, V]) Get(key K) (V, bool) {
+	sh := s.shardOf(key)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	value, ok := sh.storage[key]
+	return value, ok
+}
+
+func (s *ShardedStorage[K, V]) Set(key K, value V) {
+	sh := s.shardOf(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	sh.storage[key] = value
+}
+
+func (s *ShardedStorage[K, V]) Delete(key K) {
+	sh := s.shardOf(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	delete(sh.storage, key)
+}
+
+func (s *ShardedStorage[K, V]) Len() int {
+	total := 0
+	for _, sh := range s.shards {
+		sh.mu.RLock()
+		total += len(sh.storage)
+		sh.mu.RUnlock()
+	}
+
+	return total
+}
+
+func (s *ShardedStorage[K, V]) GetAll() []Pair[K, V] {
+	pairs := make([]Pair[K, V], 0, s.Len())
+	for _, sh := range s.shards {
+		sh.mu.RLock()
+		for key, value := range sh.storage {
+			pairs = append(pairs, Pair[K, V]{Key: key, Value: value})
+		}
+		sh.mu.RUnlock()
+	}
+
+	return pairs
+}
+
+func (s *ShardedStorage[K, V]) shardOf(key K) *shard[K, V] {
+	return s.shards[s.hash(key)%uint64(len(s.shards))]
+}
@@ -0,0 +1,124 @@
+package storage_test
+
+import (
+	"github.com/stretchr/testify/suite"
+	"strconv"
+	"testing"
+	"yadro-intern/internal/storage"
+	"yadro-intern/internal/storage/storagetest"
+)
+
+func value(i int) int {
+	return i
+}
+
+func TestInMemoryStorage(t *testing.T) {
+	suite.Run(t, &storagetest.StorageSuite[string, int]{
+		NewStorage: func(*testing.T) storage.Storage[string, int] {
+			return storage.NewInMemoryStorage[string, int]()
+		},
+		Key:   strconv.Itoa,
+		Value: value,
+	})
+}
+
+func TestInMemoryQueue(t *testing.T) {
+	suite.Run(t, &storagetest.QueueSuite[string]{
+		NewQueue: func(*testing.T) storage.Queue[string] {
+			return storage.NewInMemoryQueue[string]("")
+		},
+		Value: strconv.Itoa,
+	})
+}
+
+func TestFileStorage(t *testing.T) {
+	suite.Run(t, &storagetest.StorageSuite[string, int]{
+		NewStorage: func(t *testing.T) storage.Storage[string, int] {
+			s, err := storage.NewFileStorage[string, int](
+				t.TempDir(), storage.JSONCodec[string]{}, storage.JSONCodec[int]{},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			t.Cleanup(func() {
+				if err := s.Close(); err != nil {
+					t.Error(err)
+				}
+			})
+
+			return s
+		},
+		Key:   strconv.Itoa,
+		Value: value,
+	})
+}
+
+func TestFileQueue(t *testing.T) {
+	suite.Run(t, &storagetest.QueueSuite[string]{
+		NewQueue: func(t *testing.T) storage.Queue[string] {
+			q, err := storage.NewFileQueue[string](t.TempDir(), storage.JSONCodec[string]{}, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			t.Cleanup(func() {
+				if err := q.Close(); err != nil {
+					t.Error(err)
+				}
+			})
+
+			return q
+		},
+		Value: strconv.Itoa,
+	})
+}
+
+func TestSyncStorage(t *testing.T) {
+	suite.Run(t, &storagetest.StorageSuite[string, int]{
+		NewStorage: func(*testing.T) storage.Storage[string, int] {
+			return storage.NewSyncStorage(storage.NewInMemoryStorage[string, int]())
+		},
+		Key:   strconv.Itoa,
+		Value: value,
+	})
+}
+
+func TestSyncQueue(t *testing.T) {
+	suite.Run(t, &storagetest.QueueSuite[string]{
+		NewQueue: func(*testing.T) storage.Queue[string] {
+			return storage.NewSyncQueue(storage.NewInMemoryQueue[string](""))
+		},
+		Value: strconv.Itoa,
+	})
+}
+
+func TestShardedStorage(t *testing.T) {
+	suite.Run(t, &storagetest.StorageSuite[string, int]{
+		NewStorage: func(*testing.T) storage.Storage[string, int] {
+			return storage.NewShardedStorage[string, int](8, storage.HashString)
+		},
+		Key:   strconv.Itoa,
+		Value: value,
+	})
+}
+
+func TestOrderedStorage(t *testing.T) {
+	suite.Run(t, &storagetest.StorageSuite[string, int]{
+		NewStorage: func(*testing.T) storage.Storage[string, int] {
+			return storage.NewOrderedStorage[string, int](storage.Less[string])
+		},
+		Key:   strconv.Itoa,
+		Value: value,
+	})
+}
+
+func TestPriorityQueue(t *testing.T) {
+	suite.Run(t, &storagetest.QueueSuite[string]{
+		NewQueue: func(*testing.T) storage.Queue[string] {
+			// all elements have the same priority, so the queue is FIFO
+			return storage.NewPriorityQueue[string]("", func(string) int { return 0 })
+		},
+		Value: strconv.Itoa,
+	})
+}
@@ -0,0 +1,193 @@
+package storagetest
+
+import (
+	"github.com/stretchr/testify/suite"
+	"math/rand"
+	"testing"
+	"yadro-intern/internal/storage"
+)
+
+// QueueSuite checks, that the Queue implementation behaves as a FIFO queue.
+type QueueSuite[T comparable] struct {
+	suite.Suite
+
+	// NewQueue returns the empty queue with the NilValue for every test.
+	NewQueue func(t *testing.T) storage.Queue[T]
+	NilValue T
+
+	// Value returns the distinct value for every number.
+	Value func(i int) T
+}
+
+func (s *QueueSuite[T]) TestEmpty() {
+	q := s.NewQueue(s.T())
+
+	value, err := q.Peek()
+	s.Require().Error(err)
+	s.Require().Equal(s.NilValue, value)
+
+	value, err = q.Pop()
+	s.Require().Error(err)
+	s.Require().Equal(s.NilValue, value)
+
+	s.Require().Equal(0, q.Len())
+	s.Require().Empty(q.GetAll())
+	s.Require().False(q.Contains(s.is(0)))
+	s.Require().False(q.Remove(s.is(0)))
+}
+
+func (s *QueueSuite[T]) TestFIFO() {
+	q := s.NewQueue(s.T())
+	for i := 0; i < 3; i++ {
+		q.Push(s.Value(i))
+	}
+
+	value, err := q.Peek()
+	s.Require().NoError(err)
+	s.Require().Equal(s.Value(0), value)
+	s.Require().Equal(3, q.Len())
+
+	for i := 0; i < 3; i++ {
+		value, err = q.Pop()
+		s.Require().NoError(err)
+		s.Require().Equal(s.Value(i), value)
+	}
+
+	_, err = q.Pop()
+	s.Require().Error(err)
+}
+
+func (s *QueueSuite[T]) TestClear() {
+	q := s.NewQueue(s.T())
+	q.Push(s.Value(0))
+	q.Push(s.Value(1))
+	q.Clear()
+
+	s.Require().Equal(0, q.Len())
+	s.Require().Empty(q.GetAll())
+
+	// the queue is usable after clearing
+	q.Push(s.Value(2))
+	value, err := q.Pop()
+	s.Require().NoError(err)
+	s.Require().Equal(s.Value(2), value)
+}
+
+func (s *QueueSuite[T]) TestContainsRemove() {
+	q := s.NewQueue(s.T())
+	for _, i := range []int{0, 1, 2, 1} {
+		q.Push(s.Value(i))
+	}
+
+	s.Require().True(q.Contains(s.is(1)))
+	s.Require().False(q.Contains(s.is(3)))
+
+	// only the first matching element is removed
+	s.Require().True(q.Remove(s.is(1)))
+	s.Require().Equal([]T{s.Value(0), s.Value(2), s.Value(1)}, q.GetAll())
+
+	s.Require().True(q.Remove(s.is(1)))
+	s.Require().False(q.Remove(s.is(1)))
+	s.Require().Equal([]T{s.Value(0), s.Value(2)}, q.GetAll())
+}
+
+func (s *QueueSuite[T]) TestGetAllIsCopy() {
+	q := s.NewQueue(s.T())
+	q.Push(s.Value(0))
+
+	values := q.GetAll()
+	values[0] = s.Value(1)
+
+	value, err := q.Peek()
+	s.Require().NoError(err)
+	s.Require().Equal(s.Value(0), value)
+}
+
+func (s *QueueSuite[T]) TestLargeVolume() {
+	q := s.NewQueue(s.T())
+	for i := 0; i < largeVolume; i++ {
+		q.Push(s.Value(i))
+	}
+
+	s.Require().Equal(largeVolume, q.Len())
+	for i := 0; i < largeVolume/2; i++ {
+		value, err := q.Pop()
+		s.Require().NoError(err)
+		s.Require().Equal(s.Value(i), value)
+	}
+
+	values := q.GetAll()
+	s.Require().Len(values, largeVolume/2)
+	s.Require().Equal(s.Value(largeVolume/2), values[0])
+	s.Require().Equal(s.Value(largeVolume-1), values[len(values)-1])
+}
+
+// TestRandomized compares the queue with the slice after every random operation.
+func (s *QueueSuite[T]) TestRandomized() {
+	var (
+		q         = s.NewQueue(s.T())
+		reference []T
+		rnd       = rand.New(rand.NewSource(randomSeed)) // #nosec G404
+	)
+
+	for op := 0; op < randomOps; op++ {
+		switch n := rnd.Intn(20); {
+		case n < 10:
+			value := s.Value(rnd.Intn(32))
+			q.Push(value)
+			reference = append(reference, value)
+		case n < 15:
+			value, err := q.Pop()
+			if len(reference) == 0 {
+				s.Require().Error(err, "operation %d", op)
+				s.Require().Equal(s.NilValue, value, "operation %d", op)
+				break
+			}
+
+			s.Require().NoError(err, "operation %d", op)
+			s.Require().Equal(reference[0], value, "operation %d", op)
+			reference = reference[1:]
+		case n < 19:
+			i := rnd.Intn(32)
+			idx := indexOf(reference, s.Value(i))
+			s.Require().Equal(idx != -1, q.Contains(s.is(i)), "operation %d", op)
+			s.Require().Equal(idx != -1, q.Remove(s.is(i)), "operation %d", op)
+			if idx != -1 {
+				reference = append(reference[:idx:idx], reference[idx+1:]...)
+			}
+		default:
+			q.Clear()
+			reference = nil
+		}
+
+		s.Require().Equal(len(reference), q.Len(), "operation %d", op)
+		if len(reference) > 0 {
+			value, err := q.Peek()
+			s.Require().NoError(err, "operation %d", op)
+			s.Require().Equal(reference[0], value, "operation %d", op)
+		}
+	}
+
+	s.Require().Equal(len(reference), len(q.GetAll()))
+	for idx, value := range q.GetAll() {
+		s.Require().Equal(reference[idx], value)
+	}
+}
+
+// is returns the predicate matching the value of the number.
+func (s *QueueSuite[T]) is(i int) func(T) bool {
+	expected := s.Value(i)
+	return func(value T) bool {
+		return value == expected
+	}
+}
+
+func indexOf[T comparable](values []T, value T) int {
+	for idx, v := range values {
+		if v == value {
+			return idx
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,169 @@
+// Package storagetest provides the conformance suites for the storage implementations.
+//
+// A new backend is checked by running the suites with its constructor:
+//
+//	suite.Run(t, &storagetest.StorageSuite[string, int]{
+//		NewStorage: func(t *testing.T) storage.Storage[string, int] { ... },
+//		Key:        strconv.Itoa,
+//		Value:      func(i int) int { return i },
+//	})
+package storagetest
+
+import (
+	"github.com/stretchr/testify/suite"
+	"math/rand"
+	"testing"
+	"yadro-intern/internal/storage"
+)
+
+// operations of the randomized tests
+const (
+	randomOps  = 5000
+	randomSeed = 1
+)
+
+// largeVolume is the count of elements in the large volume tests.
+const largeVolume = 10000
+
+// StorageSuite checks, that the Storage implementation behaves as a map.
+type StorageSuite[K, V comparable] struct {
+	suite.Suite
+
+	// NewStorage returns the empty storage for every test.
+	NewStorage func(t *testing.T) storage.Storage[K, V]
+
+	// Key and Value return the distinct key and value for every number.
+	Key   func(i int) K
+	Value func(i int) V
+}
+
+func (s *StorageSuite[K, V]) TestEmpty() {
+	st := s.NewStorage(s.T())
+
+	_, ok := st.Get(s.Key(0))
+	s.Require().False(ok)
+	s.Require().Equal(0, st.Len())
+	s.Require().Empty(st.GetAll())
+
+	// deleting the missing key does nothing
+	st.Delete(s.Key(0))
+	s.Require().Equal(0, st.Len())
+}
+
+func (s *StorageSuite[K, V]) TestSetGet() {
+	st := s.NewStorage(s.T())
+	st.Set(s.Key(1), s.Value(1))
+	st.Set(s.Key(2), s.Value(2))
+
+	value, ok := st.Get(s.Key(1))
+	s.Require().True(ok)
+	s.Require().Equal(s.Value(1), value)
+
+	value, ok = st.Get(s.Key(2))
+	s.Require().True(ok)
+	s.Require().Equal(s.Value(2), value)
+	s.Require().Equal(2, st.Len())
+}
+
+func (s *StorageSuite[K, V]) TestOverwrite() {
+	st := s.NewStorage(s.T())
+	st.Set(s.Key(1), s.Value(1))
+	st.Set(s.Key(1), s.Value(2))
+
+	value, ok := st.Get(s.Key(1))
+	s.Require().True(ok)
+	s.Require().Equal(s.Value(2), value)
+	s.Require().Equal(1, st.Len())
+}
+
+func (s *StorageSuite[K, V]) TestDelete() {
+	st := s.NewStorage(s.T())
+	st.Set(s.Key(1), s.Value(1))
+	st.Set(s.Key(2), s.Value(2))
+	st.Delete(s.Key(1))
+	st.Delete(s.Key(3))
+
+	_, ok := st.Get(s.Key(1))
+	s.Require().False(ok)
+	s.Require().Equal(1, st.Len())
+	s.Require().Equal([]storage.Pair[K, V]{{Key: s.Key(2), Value: s.Value(2)}}, st.GetAll())
+
+	// the deleted key can be set again
+	st.Set(s.Key(1), s.Value(3))
+	value, ok := st.Get(s.Key(1))
+	s.Require().True(ok)
+	s.Require().Equal(s.Value(3), value)
+}
+
+func (s *StorageSuite[K, V]) TestGetAll() {
+	st := s.NewStorage(s.T())
+
+	expected := make([]storage.Pair[K, V], 0, 10)
+	for i := 0; i < 10; i++ {
+		st.Set(s.Key(i), s.Value(i))
+		expected = append(expected, storage.Pair[K, V]{Key: s.Key(i), Value: s.Value(i)})
+	}
+
+	s.Require().ElementsMatch(expected, st.GetAll())
+}
+
+func (s *StorageSuite[K, V]) TestLargeVolume() {
+	st := s.NewStorage(s.T())
+	for i := 0; i < largeVolume; i++ {
+		st.Set(s.Key(i), s.Value(i))
+	}
+
+	for i := 0; i < largeVolume; i += 2 {
+		st.Delete(s.Key(i))
+	}
+
+	s.Require().Equal(largeVolume/2, st.Len())
+	s.Require().Len(st.GetAll(), largeVolume/2)
+
+	for i := 0; i < largeVolume; i++ {
+		value, ok := st.Get(s.Key(i))
+		if i%2 == 0 {
+			s.Require().False(ok, "key %d", i)
+			continue
+		}
+
+		s.Require().True(ok, "key %d", i)
+		s.Require().Equal(s.Value(i), value)
+	}
+}
+
+// TestRandomized compares the storage with the map after every random operation.
+func (s *StorageSuite[K, V]) TestRandomized() {
+	var (
+		st        = s.NewStorage(s.T())
+		reference = make(map[K]V)
+		rnd       = rand.New(rand.NewSource(randomSeed)) // #nosec G404
+	)
+
+	for op := 0; op < randomOps; op++ {
+		key := s.Key(rnd.Intn(64))
+
+		switch rnd.Intn(3) {
+		case 0, 1:
+			value := s.Value(rnd.Intn(1000))
+			st.Set(key, value)
+			reference[key] = value
+		case 2:
+			st.Delete(key)
+			delete(reference, key)
+		}
+
+		expected, expectedOk := reference[key]
+		value, ok := st.Get(key)
+		s.Require().Equal(expectedOk, ok, "operation %d", op)
+		s.Require().Equal(expected, value, "operation %d", op)
+		s.Require().Equal(len(reference), st.Len(), "operation %d", op)
+	}
+
+	expected := make([]storage.Pair[K, V], 0, len(reference))
+	for key, value := range reference {
+		expected = append(expected, storage.Pair[K, V]{Key: key, Value: value})
+	}
+
+	s.Require().ElementsMatch(expected, st.GetAll())
+}
@@ -0,0 +1,120 @@
+package storage
+
+import "sync"
+
+// SyncStorage is the Storage safe for concurrent use,
+// readers share the lock, so they don't block each other.
+type SyncStorage[K, V comparable] struct {
+	mu      sync.RWMutex
+	storage Storage[K, V]
+}
+
+// NewSyncStorage wraps the storage, it must not be used directly after that.
+func NewSyncStorage[K, V comparable](storage Storage[K, V]) Storage[K, V] {
+	return &SyncStorage[K, V]{storage: storage}
+}
+
+func (s *SyncStorage[K, V]) Get(key K) (V, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.storage.Get(key)
+}
+
+func (s *SyncStorage[K, V]) Set(key K, value V) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.storage.Set(key, value)
+}
+
+func (s *SyncStorage[K, V]) Delete(key K) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.storage.Delete(key)
+}
+
+func (s *SyncStorage[K, V]) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.storage.Len()
+}
+
+func (s *SyncStorage[K, V]) GetAll() []Pair[K, V] {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.storage.GetAll()
+}
+
+// SyncQueue is the Queue safe for concurrent use.
+//
+// Predicates of Contains and Remove are called under the lock,
+// so they must not use the queue.
+type SyncQueue[T any] struct {
+	mu    sync.Mutex
+	queue Queue[T]
+}
+
+// NewSyncQueue wraps the queue, it must not be used directly after that.
+func NewSyncQueue[T any](queue Queue[T]) Queue[T] {
+	return &SyncQueue[T]{queue: queue}
+}
+
+func (q *SyncQueue[T]) Push(value T) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.queue.Push(value)
+}
+
+func (q *SyncQueue[T]) Pop() (T, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.queue.Pop()
+}
+
+func (q *SyncQueue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.queue.Len()
+}
+
+func (q *SyncQueue[T]) Peek() (T, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.queue.Peek()
+}
+
+func (q *SyncQueue[T]) Clear() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.queue.Clear()
+}
+
+func (q *SyncQueue[T]) Contains(match func(T) bool) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.queue.Contains(match)
+}
+
+func (q *SyncQueue[T]) Remove(match func(T) bool) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.queue.Remove(match)
+}
+
+func (q *SyncQueue[T]) GetAll() []T {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.queue.GetAll()
+}
@@ -0,0 +1,188 @@
+package storage_test
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"yadro-intern/internal/storage"
+)
+
+// concurrency of the stress tests, run them with -race
+const (
+	writers   = 8
+	readers   = 8
+	perWriter = 1000
+)
+
+func concurrentStorages() map[string]func() storage.Storage[int, int] {
+	return map[string]func() storage.Storage[int, int]{
+		"sync": func() storage.Storage[int, int] {
+			return storage.NewSyncStorage(storage.NewInMemoryStorage[int, int]())
+		},
+		"sharded": func() storage.Storage[int, int] {
+			return storage.NewShardedStorage[int, int](16, storage.HashInt)
+		},
+	}
+}
+
+func TestStorageStress(t *testing.T) {
+	for name, newStorage := range concurrentStorages() {
+		newStorage := newStorage
+		t.Run(name, func(t *testing.T) {
+			s := newStorage()
+
+			var wg sync.WaitGroup
+			for r := 0; r < readers; r++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < perWriter; i++ {
+						key := i * writers % (writers * perWriter)
+						if value, ok := s.Get(key); ok && value != key {
+							t.Errorf("key %d has value %d", key, value)
+							return
+						}
+
+						// copying all elements is expensive, so it's done rarely
+						if i%64 != 0 {
+							continue
+						}
+
+						for _, pair := range s.GetAll() {
+							if pair.Value != pair.Key {
+								t.Errorf("key %d has value %d", pair.Key, pair.Value)
+								return
+							}
+						}
+					}
+				}()
+			}
+
+			for w := 0; w < writers; w++ {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+					for i := 0; i < perWriter; i++ {
+						key := w*perWriter + i
+						s.Set(key, key)
+						if _, ok := s.Get(key); !ok {
+							t.Errorf("key %d is not found after setting", key)
+						}
+
+						// every second key is deleted
+						if i%2 == 0 {
+							s.Delete(key)
+						}
+					}
+				}(w)
+			}
+
+			wg.Wait()
+
+			if s.Len() != writers*perWriter/2 {
+				t.Fatalf("expected %d elements, got %d", writers*perWriter/2, s.Len())
+			}
+		})
+	}
+}
+
+func TestQueueStress(t *testing.T) {
+	q := storage.NewSyncQueue(storage.NewInMemoryQueue[int](-1))
+
+	var (
+		wg     sync.WaitGroup
+		popped = make(chan int, writers*perWriter)
+	)
+
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				q.Push(w*perWriter + i)
+			}
+		}(w)
+	}
+
+	for r := 0; r < readers; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < writers*perWriter/readers; {
+				value, err := q.Pop()
+				if err != nil {
+					continue
+				}
+
+				popped <- value
+				i++
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(popped)
+
+	seen := make(map[int]bool, writers*perWriter)
+	for value := range popped {
+		if seen[value] {
+			t.Fatalf("value %d is popped twice", value)
+		}
+
+		seen[value] = true
+	}
+
+	if len(seen) != writers*perWriter || q.Len() != 0 {
+		t.Fatalf("expected %d popped values and the empty queue, got %d and %d", writers*perWriter, len(seen), q.Len())
+	}
+}
+
+// BenchmarkStorage compares the concurrent storages with 90% and 10% of reads.
+func BenchmarkStorage(b *testing.B) {
+	const keys = 1 << 12
+
+	for name, newStorage := range concurrentStorages() {
+		for _, readPercent := range []int{90, 10} {
+			newStorage := newStorage
+			readPercent := readPercent
+			b.Run(name+"/reads-"+strconv.Itoa(readPercent), func(b *testing.B) {
+				s := newStorage()
+				for key := 0; key < keys; key++ {
+					s.Set(key, key)
+				}
+
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					i := 0
+					for pb.Next() {
+						key := i % keys
+						if i%100 < readPercent {
+							s.Get(key)
+						} else {
+							s.Set(key, i)
+						}
+
+						i++
+					}
+				})
+			})
+		}
+	}
+}
+
+func BenchmarkQueue(b *testing.B) {
+	q := storage.NewSyncQueue(storage.NewInMemoryQueue[int](-1))
+
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			if i%2 == 0 {
+				q.Push(i)
+			} else {
+				_, _ = q.Pop()
+			}
+
+			i++
+		}
+	})
+}
@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"yadro-intern/internal/logfile"
+)
+
+// files of the persistent storage directory
+const (
+	walFile      = "wal.log"
+	snapshotFile = "snapshot.json"
+)
+
+// minCompactOps is the least count of logged operations, which causes the compaction.
+const minCompactOps = 256
+
+// walOp is the operation of the write-ahead log.
+type walOp struct {
+
+	// Seq is the number of the operation, operations up to the snapshot number are skipped.
+	Seq   uint64          `json:"seq"`
+	Op    string          `json:"op"`
+	Key   json.RawMessage `json:"key,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+	Index int             `json:"index,omitempty"`
+}
+
+// wal is the write-ahead log with the snapshot, which the log is compacted to.
+//
+// Every operation is synced to the disk before it's applied, so it survives the crash.
+type wal struct {
+	dir string
+	f   *os.File
+	seq uint64
+
+	// ops is the count of operations in the log since the last compaction.
+	ops int
+}
+
+// openWAL reads the snapshot and the operations after it, then opens the log for appending.
+//
+// The last line without the line break is the operation torn by the crash, it's cut off.
+func openWAL(dir string, snapshot any) (*wal, []*walOp, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, nil, fmt.Errorf("could not create storage directory: %s", err)
+	}
+
+	snapshotSeq, err := readSnapshot(filepath.Join(dir, snapshotFile), snapshot)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var ops []*walOp
+	f, err := logfile.Open(filepath.Join(dir, walFile), func(line []byte) error {
+		var op walOp
+		if err := json.Unmarshal(line, &op); err != nil {
+			return fmt.Errorf("invalid operation %d: %s", len(ops)+1, err)
+		}
+
+		ops = append(ops, &op)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open write-ahead log: %s", err)
+	}
+
+	w := &wal{dir: dir, f: f, seq: snapshotSeq, ops: len(ops)}
+
+	// operations are already in the snapshot, if the crash happened
+	// after the snapshot is written, but before the log is cleared
+	pending := make([]*walOp, 0, len(ops))
+	for _, op := range ops {
+		if op.Seq > snapshotSeq {
+			pending = append(pending, op)
+			w.seq = op.Seq
+		}
+	}
+
+	return w, pending, nil
+}
+
+// append writes the operation to the log and syncs it.
+func (w *wal) append(op *walOp) error {
+	op.Seq = w.seq + 1
+
+	line, err := json.Marshal(op)
+	if err != nil {
+		return fmt.Errorf("failed to encode operation: %s", err)
+	}
+
+	if _, err = w.f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write operation: %s", err)
+	}
+
+	if err = w.f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync operation: %s", err)
+	}
+
+	w.seq = op.Seq
+	w.ops++
+	return nil
+}
+
+// shouldCompact reports, whether the log is long enough comparing to the stored elements.
+func (w *wal) shouldCompact(elements int) bool {
+	return w.ops >= minCompactOps && w.ops >= elements
+}
+
+// compact replaces the snapshot with the current state and clears the log.
+func (w *wal) compact(snapshot any) error {
+	content, err := json.Marshal(struct {
+		Seq  uint64 `json:"seq"`
+		Data any    `json:"data"`
+	}{Seq: w.seq, Data: snapshot})
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %s", err)
+	}
+
+	if err = logfile.WriteAtomically(filepath.Join(w.dir, snapshotFile), content); err != nil {
+		return err
+	}
+
+	if err = w.f.Truncate(0); err != nil {
+		return fmt.Errorf("failed to clear write-ahead log: %s", err)
+	}
+
+	if _, err = w.f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to clear write-ahead log: %s", err)
+	}
+
+	if err = w.f.Sync(); err != nil {
+		return fmt.Errorf("failed to clear write-ahead log: %s", err)
+	}
+
+	w.ops = 0
+	return nil
+}
+
+func (w *wal) close() error {
+	return w.f.Close()
+}
+
+// readSnapshot decodes the snapshot data to the value and returns the snapshot number,
+// the missing snapshot has number 0.
+func readSnapshot(path string, data any) (uint64, error) {
+	content, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("could not read snapshot: %s", err)
+	}
+
+	snapshot := struct {
+		Seq  uint64 `json:"seq"`
+		Data any    `json:"data"`
+	}{Data: data}
+
+	if err = json.Unmarshal(content, &snapshot); err != nil {
+		return 0, fmt.Errorf("invalid snapshot: %s", err)
+	}
+
+	return snapshot.Seq, nil
+}